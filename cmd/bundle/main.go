@@ -1,25 +1,77 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"os"
+
+	"github.com/telkomindonesia/openapi-utils/pkg/bundler"
+	"github.com/telkomindonesia/openapi-utils/pkg/gitfs"
+	"github.com/telkomindonesia/openapi-utils/pkg/overlay"
+	"github.com/telkomindonesia/openapi-utils/pkg/reference"
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		log.Fatalf("Usage: %s <path-to-main-spec> [<path-to-new-spec>]\n", os.Args[0])
+	ref := flag.String("git-ref", "", "read the spec as it exists at the given git revision instead of the working tree")
+	strict := flag.Bool("strict", false, "fail when any reference can not be resolved instead of only logging it")
+	preserve := flag.Bool("preserve-source", false, "keep the comments, anchors and key order of the source files")
+	annotate := flag.Bool("x-source", false, "annotate every bundled component with the file and JSON pointer it was copied from")
+	var overlays []string
+	flag.Func("overlay", "apply the overlay at the given path to the result, may be repeated", func(s string) error {
+		overlays = append(overlays, s)
+		return nil
+	})
+	flag.Parse()
+	if flag.NArg() < 1 {
+		log.Fatalf("Usage: %s [-git-ref <revision>] [-strict] [-preserve-source] [-x-source] [-overlay <path-to-overlay>]... <path-to-main-spec> [<path-to-new-spec>]\n", os.Args[0])
 	}
 
-	bytes, err := bundleFile(os.Args[1])
+	ctx := context.Background()
+	opts := []bundler.Option{bundler.WithStrict(*strict), bundler.WithPreserveSource(*preserve), bundler.WithSourceAnnotations(*annotate)}
+	src := flag.Arg(0)
+	var bytes []byte
+	var err error
+	switch *ref {
+	case "":
+		bytes, err = bundler.Bundle(ctx, src, opts...)
+	default:
+		repo, name, lerr := gitfs.Locate(ctx, src)
+		if lerr != nil {
+			log.Fatalln("fail to locate git repository:", lerr)
+		}
+		fsys, lerr := gitfs.New(ctx, repo, *ref)
+		if lerr != nil {
+			log.Fatalln("fail to read git revision:", lerr)
+		}
+		defer fsys.Close()
+		bytes, err = bundler.BundleFS(ctx, fsys, name, opts...)
+	}
+	var rerrs reference.Errors
+	if errors.As(err, &rerrs) {
+		for _, e := range rerrs {
+			fmt.Fprintln(os.Stderr, e)
+		}
+		log.Fatalf("fail to bundle file: %d unresolved references\n", len(rerrs))
+	}
 	if err != nil {
 		log.Fatalln("fail to bundle file:", err)
 	}
 
-	bytes = append([]byte("# Code generated by openapi-utils. DO NOT EDIT.\n"), bytes...)
-	dst := ""
-	if len(os.Args) > 2 {
-		dst = os.Args[2]
+	for _, path := range overlays {
+		o, err := overlay.Load(path)
+		if err != nil {
+			log.Fatalln("fail to load overlay:", err)
+		}
+		if bytes, err = o.Apply(bytes); err != nil {
+			log.Fatalln("fail to apply overlay:", err)
+		}
 	}
+
+	bytes = append([]byte("# Code generated by openapi-utils. DO NOT EDIT.\n"), bytes...)
+	dst := flag.Arg(1)
 	switch dst {
 	case "":
 		if _, err := os.Stdout.Write(bytes); err != nil {
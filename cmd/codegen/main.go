@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/telkomindonesia/openapi-utils/pkg/codegen"
+	"github.com/telkomindonesia/openapi-utils/pkg/proxy"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "go":
+		generateGo(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	log.Fatalf("Usage: %s go [-package <name>] [-proxy] [-env <environment>] <path-to-spec> [<path-to-output>]\n", os.Args[0])
+}
+
+func generateGo(args []string) {
+	fs := flag.NewFlagSet("go", flag.ExitOnError)
+	pkg := fs.String("package", "api", "name of the package of the generated code")
+	isProxy := fs.Bool("proxy", false, "compile the spec as a proxy spec and generate the forwarding to its upstreams")
+	env := fs.String("env", "", "environment used to select the upstream servers of a proxy spec")
+	_ = fs.Parse(args)
+	if fs.NArg() < 1 {
+		usage()
+	}
+
+	ctx := context.Background()
+	opts := []codegen.Option{codegen.WithPackage(*pkg)}
+	var bytes []byte
+	var err error
+	switch {
+	case *isProxy:
+		_, doc, cerr := proxy.Compile(ctx, fs.Arg(0), proxy.WithEnvironment(*env))
+		if cerr != nil {
+			log.Fatalln("fail to compile proxy spec:", cerr)
+		}
+		bytes, err = codegen.GenerateGoDocument(doc, opts...)
+	default:
+		bytes, err = codegen.GenerateGo(ctx, fs.Arg(0), opts...)
+	}
+	if err != nil {
+		log.Fatalln("fail to generate go code:", err)
+	}
+
+	dst := fs.Arg(1)
+	switch dst {
+	case "":
+		if _, err := os.Stdout.Write(bytes); err != nil {
+			log.Fatalln("fail to write stdout:", err)
+		}
+	default:
+		if err := os.WriteFile(dst, bytes, 0644); err != nil {
+			log.Fatalln("fail to write file:", err)
+		}
+	}
+}
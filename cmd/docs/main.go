@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/telkomindonesia/openapi-utils/pkg/docs"
+	"github.com/telkomindonesia/openapi-utils/pkg/proxy"
+)
+
+func main() {
+	isProxy := flag.Bool("proxy", false, "compile the spec as a proxy spec before documenting it")
+	env := flag.String("env", "", "environment used to select the upstream servers of a proxy spec")
+	templates := flag.String("templates", "", "directory holding templates replacing the default ones of the same name")
+	format := flag.String("format", "html", "format of the documentation, either html or markdown")
+	single := flag.Bool("single", false, "render the markdown documentation as a single file instead of one per tag, only with -format markdown")
+	flag.Parse()
+	if flag.NArg() < 2 {
+		log.Fatalf("Usage: %s [-format html|markdown] [-single] [-proxy] [-env <environment>] [-templates <dir>] <path-to-spec> <path-to-output-dir>\n", os.Args[0])
+	}
+
+	renderers := map[string]struct {
+		spec     func(ctx context.Context, specPath string, opts ...docs.Option) ([]docs.File, error)
+		document func(doc libopenapi.Document, opts ...docs.Option) ([]docs.File, error)
+	}{
+		"html":     {docs.RenderHTML, docs.RenderHTMLDocument},
+		"markdown": {docs.RenderMarkdown, docs.RenderMarkdownDocument},
+	}
+	render, ok := renderers[*format]
+	if !ok {
+		log.Fatalf("unsupported format '%s'\n", *format)
+	}
+	if *single && *format != "markdown" {
+		log.Fatalf("-single is only supported with -format markdown\n")
+	}
+
+	ctx := context.Background()
+	opts := []docs.Option{docs.WithSingleFile(*single)}
+	if *templates != "" {
+		opts = append(opts, docs.WithTemplates(os.DirFS(*templates)))
+	}
+	var files []docs.File
+	var err error
+	switch {
+	case *isProxy:
+		pe, perr := proxy.NewProxyExtension(ctx, flag.Arg(0), proxy.WithEnvironment(*env))
+		if perr != nil {
+			log.Fatalln("fail to load proxy spec:", perr)
+		}
+		_, doc, _, cerr := pe.CreateProxyDoc()
+		if cerr != nil {
+			log.Fatalln("fail to compile proxy spec:", cerr)
+		}
+		files, err = render.document(doc, append(opts, docs.WithProxyExtension(&pe))...)
+	default:
+		files, err = render.spec(ctx, flag.Arg(0), opts...)
+	}
+	if err != nil {
+		log.Fatalln("fail to render docs:", err)
+	}
+
+	dir := flag.Arg(1)
+	for _, f := range files {
+		dst := filepath.Join(dir, filepath.FromSlash(f.Name))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			log.Fatalln("fail to create directory:", err)
+		}
+		if err := os.WriteFile(dst, f.Content, 0644); err != nil {
+			log.Fatalln("fail to write file:", err)
+		}
+	}
+}
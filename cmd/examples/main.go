@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/telkomindonesia/openapi-utils/pkg/examples"
+	"github.com/telkomindonesia/openapi-utils/pkg/reference"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "generate":
+		generate(os.Args[2:])
+	case "validate":
+		validate(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	log.Fatalf("Usage: %s generate [-write] <path-to-main-spec> [<path-to-output>]\n"+
+		"       %s validate <path-to-main-spec>\n", os.Args[0], os.Args[0])
+}
+
+func generate(args []string) {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	write := fs.Bool("write", false, "write the bundled spec with the generated examples under components/examples instead of listing them")
+	_ = fs.Parse(args)
+	if fs.NArg() < 1 {
+		usage()
+	}
+
+	ctx := context.Background()
+	var bytes []byte
+	var err error
+	switch {
+	case *write:
+		bytes, err = examples.Write(ctx, fs.Arg(0))
+		if err != nil {
+			log.Fatalln("fail to write examples:", err)
+		}
+		bytes = append([]byte("# Code generated by openapi-utils. DO NOT EDIT.\n"), bytes...)
+	default:
+		e, gerr := examples.Generate(ctx, fs.Arg(0))
+		if gerr != nil {
+			log.Fatalln("fail to generate examples:", gerr)
+		}
+		if bytes, err = json.MarshalIndent(e, "", "  "); err != nil {
+			log.Fatalln("fail to render examples:", err)
+		}
+		bytes = append(bytes, '\n')
+	}
+
+	dst := fs.Arg(1)
+	switch dst {
+	case "":
+		if _, err := os.Stdout.Write(bytes); err != nil {
+			log.Fatalln("fail to write stdout:", err)
+		}
+	default:
+		if err := os.WriteFile(dst, bytes, 0644); err != nil {
+			log.Fatalln("fail to write file:", err)
+		}
+	}
+}
+
+func validate(args []string) {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	_ = fs.Parse(args)
+	if fs.NArg() < 1 {
+		usage()
+	}
+
+	err := examples.Validate(context.Background(), fs.Arg(0))
+	var verrs reference.Errors
+	if errors.As(err, &verrs) {
+		for _, e := range verrs {
+			fmt.Fprintln(os.Stderr, e)
+		}
+		log.Fatalf("fail to validate examples: %d mismatches\n", len(verrs))
+	}
+	if err != nil {
+		log.Fatalln("fail to validate examples:", err)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/telkomindonesia/openapi-utils/pkg/jsonschema"
+)
+
+func main() {
+	draft := flag.String("draft", "2020-12", "JSON Schema draft of the exported documents, either 2020-12 or draft-07")
+	var schemas []string
+	flag.Func("schema", "export only the schema component with the given name, may be repeated", func(s string) error {
+		schemas = append(schemas, s)
+		return nil
+	})
+	flag.Parse()
+	if flag.NArg() < 2 {
+		log.Fatalf("Usage: %s [-draft <2020-12|draft-07>] [-schema <name>]... <path-to-spec> <path-to-output-dir>\n", os.Args[0])
+	}
+
+	d, err := jsonschema.ParseDraft(*draft)
+	if err != nil {
+		log.Fatalln("fail to parse draft:", err)
+	}
+	files, err := jsonschema.Export(context.Background(), flag.Arg(0), jsonschema.WithDraft(d), jsonschema.WithSchemas(schemas...))
+	if err != nil {
+		log.Fatalln("fail to export schemas:", err)
+	}
+
+	dir := flag.Arg(1)
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		log.Fatalln("fail to create output dir:", err)
+	}
+	for _, f := range files {
+		if err = os.WriteFile(filepath.Join(dir, f.Name+".json"), f.Schema, 0644); err != nil {
+			log.Fatalln("fail to write file:", err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/telkomindonesia/openapi-utils/pkg/bundler"
+	"github.com/telkomindonesia/openapi-utils/pkg/gitfs"
+	"github.com/telkomindonesia/openapi-utils/pkg/reference"
+)
+
+func main() {
+	var f bundler.Filter
+	list(&f.Tags, "tag", "keep the operations having the tag")
+	list(&f.Paths, "path", "keep the operations whose path matches the glob, ** matching across segments")
+	list(&f.OperationIDs, "operation-id", "keep the operation having the operation id")
+	list(&f.Audiences, "audience", "keep the operations whose x-audience holds the audience")
+	list(&f.ExcludeTags, "exclude-tag", "drop the operations having the tag")
+	list(&f.ExcludePaths, "exclude-path", "drop the operations whose path matches the glob")
+	list(&f.ExcludeOperationIDs, "exclude-operation-id", "drop the operation having the operation id")
+	list(&f.ExcludeAudiences, "exclude-audience", "drop the operations whose x-audience holds the audience")
+	flag.BoolVar(&f.ExcludeInternal, "exclude-internal", false, "drop the operations marked with x-internal: true")
+	ref := flag.String("git-ref", "", "read the spec as it exists at the given git revision instead of the working tree")
+	flag.Parse()
+	if flag.NArg() < 1 {
+		log.Fatalf("Usage: %s [-tag <tag>]... [-path <glob>]... [-operation-id <id>]... [-audience <audience>]... "+
+			"[-exclude-tag <tag>]... [-exclude-path <glob>]... [-exclude-operation-id <id>]... [-exclude-audience <audience>]... "+
+			"[-exclude-internal] [-git-ref <revision>] <path-to-main-spec> [<path-to-new-spec>]\n", os.Args[0])
+	}
+
+	ctx := context.Background()
+	src := flag.Arg(0)
+	var bytes []byte
+	var err error
+	switch *ref {
+	case "":
+		bytes, err = bundler.Bundle(ctx, src, bundler.WithFilter(f))
+	default:
+		repo, name, lerr := gitfs.Locate(ctx, src)
+		if lerr != nil {
+			log.Fatalln("fail to locate git repository:", lerr)
+		}
+		fsys, lerr := gitfs.New(ctx, repo, *ref)
+		if lerr != nil {
+			log.Fatalln("fail to read git revision:", lerr)
+		}
+		defer fsys.Close()
+		bytes, err = bundler.BundleFS(ctx, fsys, name, bundler.WithFilter(f))
+	}
+	var rerrs reference.Errors
+	if errors.As(err, &rerrs) {
+		for _, e := range rerrs {
+			fmt.Fprintln(os.Stderr, e)
+		}
+		log.Fatalf("fail to filter file: %d unresolved references\n", len(rerrs))
+	}
+	if err != nil {
+		log.Fatalln("fail to filter file:", err)
+	}
+
+	bytes = append([]byte("# Code generated by openapi-utils. DO NOT EDIT.\n"), bytes...)
+	dst := flag.Arg(1)
+	switch dst {
+	case "":
+		if _, err := os.Stdout.Write(bytes); err != nil {
+			log.Fatalln("fail to write stdout:", err)
+		}
+	default:
+		if err := os.WriteFile(dst, bytes, 0644); err != nil {
+			log.Fatalln("fail to write file:", err)
+		}
+	}
+}
+
+// list registers a flag that may be repeated, appending every value to s.
+func list(s *[]string, name string, usage string) {
+	flag.Func(name, usage+", may be repeated", func(v string) error {
+		*s = append(*s, v)
+		return nil
+	})
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+type envoyConfig struct {
+	StaticResources envoyStaticResources `yaml:"static_resources"`
+}
+
+type envoyStaticResources struct {
+	Listeners []envoyListener `yaml:"listeners"`
+	Clusters  []envoyCluster  `yaml:"clusters"`
+}
+
+type envoyListener struct {
+	Name         string              `yaml:"name"`
+	Address      envoyAddress        `yaml:"address"`
+	FilterChains []envoyFilterChains `yaml:"filter_chains"`
+}
+
+type envoyAddress struct {
+	SocketAddress envoySocketAddress `yaml:"socket_address"`
+}
+
+type envoySocketAddress struct {
+	Address   string `yaml:"address"`
+	PortValue int    `yaml:"port_value"`
+}
+
+type envoyFilterChains struct {
+	Filters []envoyFilter `yaml:"filters"`
+}
+
+type envoyFilter struct {
+	Name        string `yaml:"name"`
+	TypedConfig any    `yaml:"typed_config"`
+}
+
+type envoyHTTPConnectionManager struct {
+	Type        string            `yaml:"@type"`
+	StatPrefix  string            `yaml:"stat_prefix"`
+	RouteConfig envoyRouteConfig  `yaml:"route_config"`
+	HTTPFilters []envoyHTTPFilter `yaml:"http_filters"`
+}
+
+type envoyHTTPFilter struct {
+	Name        string         `yaml:"name"`
+	TypedConfig map[string]any `yaml:"typed_config"`
+}
+
+type envoyRouteConfig struct {
+	Name         string             `yaml:"name"`
+	VirtualHosts []envoyVirtualHost `yaml:"virtual_hosts"`
+}
+
+type envoyVirtualHost struct {
+	Name    string       `yaml:"name"`
+	Domains []string     `yaml:"domains"`
+	Routes  []envoyRoute `yaml:"routes"`
+}
+
+type envoyRoute struct {
+	Name                string              `yaml:"name"`
+	Match               envoyRouteMatch     `yaml:"match"`
+	Route               envoyRouteAction    `yaml:"route"`
+	RequestHeadersToAdd []envoyHeaderOption `yaml:"request_headers_to_add,omitempty"`
+}
+
+type envoyRouteMatch struct {
+	SafeRegex envoyRegex           `yaml:"safe_regex"`
+	Headers   []envoyHeaderMatcher `yaml:"headers"`
+}
+
+type envoyRegex struct {
+	Regex string `yaml:"regex"`
+}
+
+type envoyHeaderMatcher struct {
+	Name        string            `yaml:"name"`
+	StringMatch map[string]string `yaml:"string_match"`
+}
+
+type envoyRouteAction struct {
+	Cluster      string            `yaml:"cluster"`
+	RegexRewrite envoyRegexRewrite `yaml:"regex_rewrite"`
+}
+
+type envoyRegexRewrite struct {
+	Pattern      envoyRegex `yaml:"pattern"`
+	Substitution string     `yaml:"substitution"`
+}
+
+type envoyHeaderOption struct {
+	Header       envoyHeaderValue `yaml:"header"`
+	AppendAction string           `yaml:"append_action"`
+}
+
+type envoyHeaderValue struct {
+	Key   string `yaml:"key"`
+	Value string `yaml:"value"`
+}
+
+type envoyCluster struct {
+	Name            string              `yaml:"name"`
+	Type            string              `yaml:"type"`
+	LoadAssignment  envoyLoadAssignment `yaml:"load_assignment"`
+	TransportSocket map[string]any      `yaml:"transport_socket,omitempty"`
+}
+
+type envoyLoadAssignment struct {
+	ClusterName string           `yaml:"cluster_name"`
+	Endpoints   []envoyEndpoints `yaml:"endpoints"`
+}
+
+type envoyEndpoints struct {
+	LbEndpoints []envoyLbEndpoint `yaml:"lb_endpoints"`
+}
+
+type envoyLbEndpoint struct {
+	Endpoint envoyEndpoint `yaml:"endpoint"`
+}
+
+type envoyEndpoint struct {
+	Address envoyAddress `yaml:"address"`
+}
+
+func renderEnvoy(routes []Route) ([]byte, error) {
+	vh := envoyVirtualHost{
+		Name:    "proxy",
+		Domains: []string{"*"},
+	}
+	for _, r := range routes {
+		route := envoyRoute{
+			Name: r.Name,
+			Match: envoyRouteMatch{
+				SafeRegex: envoyRegex{Regex: r.PathRegex(false)},
+				Headers: []envoyHeaderMatcher{
+					{Name: ":method", StringMatch: map[string]string{"exact": r.Method}},
+				},
+			},
+			Route: envoyRouteAction{
+				Cluster: r.Upstream.Name,
+				RegexRewrite: envoyRegexRewrite{
+					Pattern: envoyRegex{Regex: r.PathRegex(false)},
+					Substitution: r.UpstreamPath(func(idx int, _ string) string {
+						return fmt.Sprintf(`\%d`, idx)
+					}),
+				},
+			},
+		}
+		for _, h := range r.Headers {
+			route.RequestHeadersToAdd = append(route.RequestHeadersToAdd, envoyHeaderOption{
+				Header:       envoyHeaderValue{Key: h.Name, Value: h.Value},
+				AppendAction: "OVERWRITE_IF_EXISTS_OR_ADD",
+			})
+		}
+		vh.Routes = append(vh.Routes, route)
+	}
+
+	cfg := envoyConfig{
+		StaticResources: envoyStaticResources{
+			Listeners: []envoyListener{{
+				Name:    "proxy",
+				Address: envoyAddress{SocketAddress: envoySocketAddress{Address: "0.0.0.0", PortValue: 8080}},
+				FilterChains: []envoyFilterChains{{
+					Filters: []envoyFilter{{
+						Name: "envoy.filters.network.http_connection_manager",
+						TypedConfig: envoyHTTPConnectionManager{
+							Type:        "type.googleapis.com/envoy.extensions.filters.network.http_connection_manager.v3.HttpConnectionManager",
+							StatPrefix:  "proxy",
+							RouteConfig: envoyRouteConfig{Name: "proxy", VirtualHosts: []envoyVirtualHost{vh}},
+							HTTPFilters: []envoyHTTPFilter{{
+								Name:        "envoy.filters.http.router",
+								TypedConfig: map[string]any{"@type": "type.googleapis.com/envoy.extensions.filters.http.router.v3.Router"},
+							}},
+						},
+					}},
+				}},
+			}},
+		},
+	}
+
+	for _, up := range upstreams(routes) {
+		cluster := envoyCluster{
+			Name: up.Name,
+			Type: "STRICT_DNS",
+			LoadAssignment: envoyLoadAssignment{
+				ClusterName: up.Name,
+				Endpoints: []envoyEndpoints{{
+					LbEndpoints: []envoyLbEndpoint{{
+						Endpoint: envoyEndpoint{
+							Address: envoyAddress{SocketAddress: envoySocketAddress{Address: up.Host, PortValue: up.Port}},
+						},
+					}},
+				}},
+			},
+		}
+		if up.TLS() {
+			cluster.TransportSocket = map[string]any{
+				"name": "envoy.transport_sockets.tls",
+				"typed_config": map[string]any{
+					"@type": "type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.UpstreamTlsContext",
+					"sni":   up.Host,
+				},
+			}
+		}
+		cfg.StaticResources.Clusters = append(cfg.StaticResources.Clusters, cluster)
+	}
+
+	return yaml.Marshal(cfg)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/libopenapi/orderedmap"
+	"github.com/telkomindonesia/openapi-utils/internal/util"
+	"github.com/telkomindonesia/openapi-utils/pkg/proxy"
+)
+
+type Upstream struct {
+	Name   string
+	Scheme string
+	Host   string
+	Port   int
+}
+
+func (u Upstream) TLS() bool {
+	return u.Scheme == "https"
+}
+
+func (u Upstream) URL() string {
+	return u.Scheme + "://" + u.Host + ":" + strconv.Itoa(u.Port)
+}
+
+type Header struct {
+	Name  string
+	Value string
+}
+
+type Route struct {
+	Name     string
+	Method   string
+	Path     string
+	Upstream Upstream
+	Headers  []Header
+
+	params       []string
+	upstreamPath string
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// PathRegex returns the regular expression matching the proxy path,
+// named capturing groups are used when named is true.
+func (r Route) PathRegex(named bool) string {
+	b := strings.Builder{}
+	b.WriteString("^")
+	last := 0
+	for _, loc := range pathParam.FindAllStringSubmatchIndex(r.Path, -1) {
+		b.WriteString(regexp.QuoteMeta(r.Path[last:loc[0]]))
+		if named {
+			b.WriteString("(?<" + captureName(r.Path[loc[2]:loc[3]]) + ">[^/]+)")
+		} else {
+			b.WriteString("([^/]+)")
+		}
+		last = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(r.Path[last:]))
+	b.WriteString("$")
+	return b.String()
+}
+
+// UpstreamPath returns the upstream path with each path parameter replaced by the value
+// returned by capture, which receives the parameter's 1-based position inside the proxy path.
+func (r Route) UpstreamPath(capture func(idx int, name string) string) string {
+	return pathParam.ReplaceAllStringFunc(r.upstreamPath, func(s string) string {
+		name := s[1 : len(s)-1]
+		for i, p := range r.params {
+			if p == name {
+				return capture(i+1, name)
+			}
+		}
+		return s
+	})
+}
+
+var nonAlphaNum = regexp.MustCompile("[^a-zA-Z0-9_]")
+
+func captureName(param string) string {
+	return nonAlphaNum.ReplaceAllString(param, "_")
+}
+
+func loadRoutes(ctx context.Context, specPath string, opts ...proxy.Option) (routes []Route, err error) {
+	pe, err := proxy.NewProxyExtension(ctx, specPath, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("fail to load proxy spec: %w", err)
+	}
+
+	proxied := pe.Proxied()
+	for m := range orderedmap.Iterate(ctx, pe.GetOpenAPIV3Doc().Model.Paths.PathItems) {
+		for _, method := range util.Methods {
+			op := util.GetOperation(m.Value(), method)
+			pop, ok := proxied[op]
+			if op == nil || !ok {
+				continue
+			}
+
+			route, err := newRoute(m.Key(), method, op.OperationId, pop)
+			if err != nil {
+				return nil, fmt.Errorf("invalid route for '%s %s': %w", method, m.Key(), err)
+			}
+			routes = append(routes, route)
+		}
+	}
+	return
+}
+
+func newRoute(path string, method string, operationID string, pop *proxy.ProxyOperation) (r Route, err error) {
+	r = Route{
+		Name:   operationID,
+		Method: strings.ToUpper(method),
+		Path:   path,
+	}
+	if r.Name == "" {
+		r.Name = captureName(method + path)
+	}
+	for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+		r.params = append(r.params, m[1])
+	}
+
+	r.Upstream, err = newUpstream(pop)
+	if err != nil {
+		return
+	}
+	u, _ := url.Parse(pop.GetUpstreamServerURL())
+	r.upstreamPath = strings.TrimSuffix(u.Path, "/") + pop.Path
+
+	for _, p := range pop.Inject.Parameters {
+		if p.Value == "" {
+			return r, fmt.Errorf("no value to inject for %s parameter '%s'", p.In, p.Name)
+		}
+		switch p.In {
+		case "path":
+			r.upstreamPath = strings.ReplaceAll(r.upstreamPath, "{"+p.Name+"}", p.Value)
+		case "header":
+			r.Headers = append(r.Headers, Header{Name: p.Name, Value: p.Value})
+		default:
+			return r, fmt.Errorf("injecting %s parameter '%s' is not supported", p.In, p.Name)
+		}
+	}
+
+	for _, m := range pathParam.FindAllStringSubmatch(r.upstreamPath, -1) {
+		found := false
+		for _, p := range r.params {
+			found = found || p == m[1]
+		}
+		if !found {
+			return r, fmt.Errorf("upstream path parameter '%s' is neither proxied nor injected", m[1])
+		}
+	}
+	return
+}
+
+func newUpstream(pop *proxy.ProxyOperation) (up Upstream, err error) {
+	s := pop.GetUpstreamServerURL()
+	if s == "" {
+		return up, fmt.Errorf("upstream doc of '%s' has no servers", pop.GetName())
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return up, fmt.Errorf("invalid upstream server url '%s': %w", s, err)
+	}
+	if u.Host == "" {
+		return up, fmt.Errorf("upstream server url '%s' has no host", s)
+	}
+
+	up = Upstream{
+		Name:   pop.GetName(),
+		Scheme: u.Scheme,
+		Host:   u.Hostname(),
+	}
+	if up.Scheme == "" {
+		up.Scheme = "http"
+	}
+	switch {
+	case u.Port() != "":
+		up.Port, err = strconv.Atoi(u.Port())
+		if err != nil {
+			return up, fmt.Errorf("invalid upstream server port '%s': %w", u.Port(), err)
+		}
+	case up.TLS():
+		up.Port = 443
+	default:
+		up.Port = 80
+	}
+	return
+}
+
+// upstreams returns the distinct upstreams used by the routes in order of appearance.
+func upstreams(routes []Route) (ups []Upstream) {
+	seen := map[string]struct{}{}
+	for _, r := range routes {
+		if _, ok := seen[r.Upstream.Name]; ok {
+			continue
+		}
+		seen[r.Upstream.Name] = struct{}{}
+		ups = append(ups, r.Upstream)
+	}
+	return
+}
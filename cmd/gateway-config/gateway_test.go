@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestRender(t *testing.T) {
+	routes, err := loadRoutes(context.Background(), "./testdata/spec-proxy.yml")
+	require.NoError(t, err)
+
+	for name, golden := range map[string]string{
+		"envoy": "envoy.golden.yaml",
+		"kong":  "kong.golden.yaml",
+		"nginx": "nginx.golden.conf",
+	} {
+		t.Run(name, func(t *testing.T) {
+			b, err := renderers[name](routes)
+			require.NoError(t, err)
+
+			golden := filepath.Join("testdata", golden)
+			if *update {
+				require.NoError(t, os.WriteFile(golden, b, 0644))
+			}
+			expected, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			require.Equal(t, string(expected), string(b))
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type kongConfig struct {
+	FormatVersion string        `yaml:"_format_version"`
+	Services      []kongService `yaml:"services"`
+}
+
+type kongService struct {
+	Name   string      `yaml:"name"`
+	URL    string      `yaml:"url"`
+	Routes []kongRoute `yaml:"routes"`
+}
+
+type kongRoute struct {
+	Name      string       `yaml:"name"`
+	Methods   []string     `yaml:"methods"`
+	Paths     []string     `yaml:"paths"`
+	StripPath bool         `yaml:"strip_path"`
+	Plugins   []kongPlugin `yaml:"plugins"`
+}
+
+type kongPlugin struct {
+	Name   string                       `yaml:"name"`
+	Config kongRequestTransformerConfig `yaml:"config"`
+}
+
+type kongRequestTransformerConfig struct {
+	Replace kongTransformReplace `yaml:"replace"`
+	Add     *kongTransformAdd    `yaml:"add,omitempty"`
+}
+
+type kongTransformReplace struct {
+	URI string `yaml:"uri"`
+}
+
+type kongTransformAdd struct {
+	Headers []string `yaml:"headers"`
+}
+
+func renderKong(routes []Route) ([]byte, error) {
+	cfg := kongConfig{FormatVersion: "3.0"}
+	services := map[string]int{}
+	for _, up := range upstreams(routes) {
+		services[up.Name] = len(cfg.Services)
+		cfg.Services = append(cfg.Services, kongService{Name: up.Name, URL: up.URL()})
+	}
+
+	for _, r := range routes {
+		transformer := kongRequestTransformerConfig{
+			Replace: kongTransformReplace{
+				URI: r.UpstreamPath(func(_ int, name string) string {
+					return "$(uri_captures." + captureName(name) + ")"
+				}),
+			},
+		}
+		if len(r.Headers) > 0 {
+			transformer.Add = &kongTransformAdd{}
+			for _, h := range r.Headers {
+				transformer.Add.Headers = append(transformer.Add.Headers, h.Name+":"+h.Value)
+			}
+		}
+
+		svc := &cfg.Services[services[r.Upstream.Name]]
+		svc.Routes = append(svc.Routes, kongRoute{
+			Name:    r.Name,
+			Methods: []string{r.Method},
+			// kong anchors regex paths to the beginning of the request path
+			Paths:     []string{"~" + strings.TrimPrefix(r.PathRegex(true), "^")},
+			StripPath: false,
+			Plugins:   []kongPlugin{{Name: "request-transformer", Config: transformer}},
+		})
+	}
+
+	return yaml.Marshal(cfg)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/telkomindonesia/openapi-utils/pkg/proxy"
+)
+
+var renderers = map[string]func([]Route) ([]byte, error){
+	"envoy": renderEnvoy,
+	"kong":  renderKong,
+	"nginx": renderNginx,
+}
+
+func main() {
+	env := flag.String("env", "", "environment used to select the upstream servers")
+	flag.Parse()
+	if flag.NArg() < 2 {
+		log.Fatalf("Usage: %s [-env <environment>] <envoy|kong|nginx> <path-to-proxy-spec> [<path-to-config>]\n", os.Args[0])
+	}
+
+	render, ok := renderers[flag.Arg(0)]
+	if !ok {
+		log.Fatalf("unsupported gateway: %s\n", flag.Arg(0))
+	}
+
+	routes, err := loadRoutes(context.Background(), flag.Arg(1), proxy.WithEnvironment(*env))
+	if err != nil {
+		log.Fatalln("fail to load routes:", err)
+	}
+	bytes, err := render(routes)
+	if err != nil {
+		log.Fatalln("fail to render gateway config:", err)
+	}
+	bytes = append([]byte("# Code generated by openapi-utils. DO NOT EDIT.\n"), bytes...)
+
+	dst := flag.Arg(2)
+	switch dst {
+	case "":
+		if _, err := os.Stdout.Write(bytes); err != nil {
+			log.Fatalln("fail to write stdout:", err)
+		}
+	default:
+		if err := os.WriteFile(dst, bytes, 0644); err != nil {
+			log.Fatalln("fail to write file:", err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"text/template"
+)
+
+// nginx can only select a location by path, so each location dispatches
+// the request to an internal location per method through an internal rewrite.
+var nginxTemplate = template.Must(template.New("nginx").Parse(`{{- range .Upstreams }}
+upstream {{ .Name }} {
+    server {{ .Host }}:{{ .Port }};
+}
+{{ end }}
+server {
+    listen 8080;
+{{ range .Locations }}
+    location ~ "{{ .Regex }}" {
+{{- range .Routes }}
+        if ($request_method = {{ .Method }}) {
+            rewrite ^ /_proxy/{{ .Name }} last;
+        }
+{{- end }}
+        return 405;
+    }
+{{ end }}
+{{- range .Locations }}{{ range .Routes }}
+    location = /_proxy/{{ .Name }} {
+        internal;
+{{- range .Headers }}
+        proxy_set_header {{ .Name }} "{{ .Value }}";
+{{- end }}
+        proxy_pass {{ .Upstream }}{{ .Path }}$is_args$args;
+    }
+{{ end }}{{ end -}}
+}
+`))
+
+type nginxConfig struct {
+	Upstreams []Upstream
+	Locations []*nginxLocation
+}
+
+type nginxLocation struct {
+	Regex  string
+	Routes []nginxRoute
+}
+
+type nginxRoute struct {
+	Name     string
+	Method   string
+	Upstream string
+	Path     string
+	Headers  []Header
+}
+
+func renderNginx(routes []Route) ([]byte, error) {
+	cfg := nginxConfig{Upstreams: upstreams(routes)}
+	locations := map[string]*nginxLocation{}
+	for _, r := range routes {
+		loc, ok := locations[r.Path]
+		if !ok {
+			loc = &nginxLocation{Regex: r.PathRegex(true)}
+			locations[r.Path] = loc
+			cfg.Locations = append(cfg.Locations, loc)
+		}
+
+		scheme := "http://"
+		if r.Upstream.TLS() {
+			scheme = "https://"
+		}
+		loc.Routes = append(loc.Routes, nginxRoute{
+			Name:     captureName(r.Name),
+			Method:   r.Method,
+			Upstream: scheme + r.Upstream.Name,
+			Path: r.UpstreamPath(func(_ int, name string) string {
+				return "$" + captureName(name)
+			}),
+			Headers: r.Headers,
+		})
+	}
+
+	b := bytes.Buffer{}
+	err := nginxTemplate.Execute(&b, cfg)
+	return b.Bytes(), err
+}
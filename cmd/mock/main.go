@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/telkomindonesia/openapi-utils/pkg/mock"
+	"github.com/telkomindonesia/openapi-utils/pkg/proxy"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address the mock server listens on")
+	isProxy := flag.Bool("proxy", false, "compile the spec as a proxy spec before serving it")
+	env := flag.String("env", "", "environment used to select the upstream servers of a proxy spec")
+	skipValidation := flag.Bool("skip-validation", false, "answer requests without validating them against the spec")
+	flag.Parse()
+	if flag.NArg() < 1 {
+		log.Fatalf("Usage: %s [-addr <address>] [-proxy] [-env <environment>] [-skip-validation] <path-to-spec>\n", os.Args[0])
+	}
+
+	ctx := context.Background()
+	opts := []mock.Option{mock.WithSkipValidation(*skipValidation)}
+	var srv *mock.Server
+	var err error
+	switch {
+	case *isProxy:
+		_, doc, cerr := proxy.Compile(ctx, flag.Arg(0), proxy.WithEnvironment(*env))
+		if cerr != nil {
+			log.Fatalln("fail to compile proxy spec:", cerr)
+		}
+		srv, err = mock.NewFromDocument(doc, opts...)
+	default:
+		srv, err = mock.New(ctx, flag.Arg(0), opts...)
+	}
+	if err != nil {
+		log.Fatalln("fail to load spec:", err)
+	}
+
+	log.Println("serving mock on", *addr)
+	if err = http.ListenAndServe(*addr, srv); err != nil {
+		log.Fatalln("fail to serve mock:", err)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/telkomindonesia/openapi-utils/pkg/overlay"
+)
+
+func main() {
+	flag.Parse()
+	if flag.NArg() < 2 {
+		log.Fatalf("Usage: %s <path-to-spec> <path-to-overlay> [<path-to-new-spec>]\n", os.Args[0])
+	}
+
+	bytes, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		log.Fatalln("fail to read spec:", err)
+	}
+	o, err := overlay.Load(flag.Arg(1))
+	if err != nil {
+		log.Fatalln("fail to load overlay:", err)
+	}
+	bytes, err = o.Apply(bytes)
+	if err != nil {
+		log.Fatalln("fail to apply overlay:", err)
+	}
+
+	dst := flag.Arg(2)
+	switch dst {
+	case "":
+		if _, err := os.Stdout.Write(bytes); err != nil {
+			log.Fatalln("fail to write stdout:", err)
+		}
+	default:
+		if err := os.WriteFile(dst, bytes, 0644); err != nil {
+			log.Fatalln("fail to write file:", err)
+		}
+	}
+}
@@ -2,28 +2,77 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"os"
 
-	"github.com/telkomindonesia/openapi-utils/cmd/proxy/internal/proxy"
+	"github.com/telkomindonesia/openapi-utils/pkg/gitfs"
+	"github.com/telkomindonesia/openapi-utils/pkg/overlay"
+	"github.com/telkomindonesia/openapi-utils/pkg/proxy"
+	"github.com/telkomindonesia/openapi-utils/pkg/reference"
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		log.Fatalf("Usage: %s <path-to-proxy-spec> [<path-to-new-spec>]\n", os.Args[0])
+	env := flag.String("env", "", "environment used to select the upstream servers")
+	ref := flag.String("git-ref", "", "read the specs as they exist at the given git revision instead of the working tree")
+	concurrency := flag.Int("concurrency", 0, "number of upstream specs processed at the same time, defaults to the number of CPUs")
+	cacheDir := flag.String("cache-dir", "", "directory upstream specs are cached in between runs, caching is disabled when empty")
+	var overlays []string
+	flag.Func("overlay", "apply the overlay at the given path to the result, may be repeated", func(s string) error {
+		overlays = append(overlays, s)
+		return nil
+	})
+	flag.Parse()
+	if flag.NArg() < 1 {
+		log.Fatalf("Usage: %s [-env <environment>] [-git-ref <revision>] [-concurrency <n>] [-cache-dir <dir>] [-overlay <path-to-overlay>]... <path-to-proxy-spec> [<path-to-new-spec>]\n", os.Args[0])
 	}
 
-	src := os.Args[1]
-	bytes, _, err := proxy.Compile(context.Background(), src)
+	ctx := context.Background()
+	opts := []proxy.Option{proxy.WithEnvironment(*env), proxy.WithConcurrency(*concurrency), proxy.WithCacheDir(*cacheDir)}
+	src := flag.Arg(0)
+	var bytes []byte
+	var err error
+	switch *ref {
+	case "":
+		bytes, _, err = proxy.Compile(ctx, src, opts...)
+	default:
+		repo, name, lerr := gitfs.Locate(ctx, src)
+		if lerr != nil {
+			log.Fatalln("fail to locate git repository:", lerr)
+		}
+		fsys, lerr := gitfs.New(ctx, repo, *ref)
+		if lerr != nil {
+			log.Fatalln("fail to read git revision:", lerr)
+		}
+		defer fsys.Close()
+		bytes, _, err = proxy.CompileFS(ctx, fsys, name, opts...)
+	}
+	var rerrs reference.Errors
+	if errors.As(err, &rerrs) {
+		for _, e := range rerrs {
+			fmt.Fprintln(os.Stderr, e)
+		}
+		log.Fatalf("fail to compile file: %d unresolved references\n", len(rerrs))
+	}
 	if err != nil {
-		log.Fatalln("fail to bundle file:", err)
+		log.Fatalln("fail to compile file:", err)
 	}
-	bytes = append([]byte("# Code generated by openapi-utils. DO NOT EDIT.\n"), bytes...)
 
-	dst := ""
-	if len(os.Args) > 2 {
-		dst = os.Args[2]
+	for _, path := range overlays {
+		o, err := overlay.Load(path)
+		if err != nil {
+			log.Fatalln("fail to load overlay:", err)
+		}
+		if bytes, err = o.Apply(bytes); err != nil {
+			log.Fatalln("fail to apply overlay:", err)
+		}
 	}
+
+	bytes = append([]byte("# Code generated by openapi-utils. DO NOT EDIT.\n"), bytes...)
+
+	dst := flag.Arg(1)
 	switch dst {
 	case "":
 		if _, err := os.Stdout.Write(bytes); err != nil {
@@ -0,0 +1,74 @@
+// Package testutil holds the helpers shared by the tests of several packages.
+package testutil
+
+import (
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+// CopyDir copies every file inside the directory src into the directory dst.
+func CopyDir(tb testing.TB, src string, dst string) {
+	tb.Helper()
+	walkFiles(tb, src, func(name string, b []byte) error {
+		p := filepath.Join(dst, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(p, b, 0o644)
+	})
+}
+
+// MapFS returns a file system holding every file inside the directory src under the directory prefix.
+func MapFS(tb testing.TB, src string, prefix string) fstest.MapFS {
+	tb.Helper()
+	fsys := fstest.MapFS{}
+	walkFiles(tb, src, func(name string, b []byte) error {
+		fsys[path.Join(prefix, name)] = &fstest.MapFile{Data: b}
+		return nil
+	})
+	return fsys
+}
+
+// walkFiles calls fn with the slash-separated path relative to src and the content of every file inside the directory src.
+func walkFiles(tb testing.TB, src string, fn func(name string, b []byte) error) {
+	tb.Helper()
+	err := fs.WalkDir(os.DirFS(src), ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := os.ReadFile(filepath.Join(src, filepath.FromSlash(name)))
+		if err != nil {
+			return err
+		}
+		return fn(name, b)
+	})
+	require.NoError(tb, err)
+}
+
+// GitRepo creates a Git repository in a temporary directory holding a commit tagged tag, in which
+// every file inside the directory src is placed under the directory prefix. The files are then removed
+// from the working tree, so that they can only be read from the commit. It returns the repository directory.
+func GitRepo(tb testing.TB, src string, prefix string, tag string) string {
+	tb.Helper()
+	repo := tb.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@localhost"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(tb, err, string(out))
+	}
+
+	run("init", "-q")
+	CopyDir(tb, src, filepath.Join(repo, filepath.FromSlash(prefix)))
+	run("add", ".")
+	run("commit", "-q", "-m", tag)
+	run("tag", tag)
+	run("rm", "-q", "-r", ".")
+	return repo
+}
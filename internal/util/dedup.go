@@ -0,0 +1,261 @@
+package util
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pb33f/libopenapi/orderedmap"
+	"gopkg.in/yaml.v3"
+)
+
+// PrefixedComponents is a set of components whose names and references
+// have all been prefixed with Prefix.
+type PrefixedComponents struct {
+	Prefix     string
+	Components StubComponents
+}
+
+type componentMap struct {
+	label string
+	m     *orderedmap.Map[string, *yaml.Node]
+}
+
+func (c StubComponents) componentMaps() []componentMap {
+	return []componentMap{
+		{"schemas", c.Schemas},
+		{"responses", c.Responses},
+		{"parameters", c.Parameters},
+		{"examples", c.Examples},
+		{"requestBodies", c.RequestBodies},
+		{"headers", c.Headers},
+		{"securitySchemes", c.SecuritySchemes},
+		{"links", c.Links},
+		{"callbacks", c.Callbacks},
+	}
+}
+
+type dedupEntry struct {
+	src  PrefixedComponents
+	name string
+	node *yaml.Node
+}
+
+// MergeComponents copies all components of srcs into dst.
+//
+// Components that exist under the same unprefixed name in more than one source
+// and are structurally identical, including everything they reference, are
+// copied once under their unprefixed name unless that name is already taken in dst.
+// The returned map contains the references that have to be rewritten, see RewriteReferences.
+func MergeComponents(dst StubComponents, srcs ...PrefixedComponents) (refs map[string]string) {
+	groups := map[string][]dedupEntry{}
+	order := []string{}
+	for _, src := range srcs {
+		for _, cm := range src.Components.componentMaps() {
+			for m := range orderedmap.Iterate(context.Background(), cm.m) {
+				name, ok := strings.CutPrefix(m.Key(), src.Prefix)
+				if !ok || name == "" {
+					continue
+				}
+				ref := "#/components/" + cm.label + "/" + name
+				if _, ok := groups[ref]; !ok {
+					order = append(order, ref)
+				}
+				groups[ref] = append(groups[ref], dedupEntry{src: src, name: m.Key(), node: m.Value()})
+			}
+		}
+	}
+
+	// find identical components
+	shared := map[string]struct{}{}
+	for ref, entries := range groups {
+		if len(entries) < 2 || dst.has(ref) {
+			continue
+		}
+		first := entries[0]
+		identical := true
+		for _, e := range entries[1:] {
+			if e.src.Prefix == first.src.Prefix ||
+				!nodeEqual(first.node, e.node, first.src.Prefix, e.src.Prefix) {
+				identical = false
+				break
+			}
+		}
+		if identical {
+			shared[ref] = struct{}{}
+		}
+	}
+
+	// drop components that reference non-shared ones until nothing changes
+	for changed := true; changed; {
+		changed = false
+		for ref := range shared {
+			e := groups[ref][0]
+			for _, r := range collectReferences(e.node) {
+				r = unprefixReference(r, e.src.Prefix)
+				if _, ok := groups[r]; !ok {
+					continue
+				}
+				if _, ok := shared[r]; ok {
+					continue
+				}
+				delete(shared, ref)
+				changed = true
+				break
+			}
+		}
+	}
+
+	refs = map[string]string{}
+	for _, ref := range order {
+		entries := groups[ref]
+		if _, ok := shared[ref]; ok {
+			dst.get(ref).Set(lastSegment(ref), entries[0].node)
+			for _, e := range entries {
+				refs[prefixReference(ref, e.src.Prefix)] = ref
+			}
+			continue
+		}
+		for _, e := range entries {
+			dst.get(ref).Set(e.name, e.node)
+		}
+	}
+	return
+}
+
+func (c StubComponents) get(ref string) *orderedmap.Map[string, *yaml.Node] {
+	for _, cm := range c.componentMaps() {
+		if strings.HasPrefix(ref, "#/components/"+cm.label+"/") {
+			return cm.m
+		}
+	}
+	return nil
+}
+
+func (c StubComponents) has(ref string) bool {
+	m := c.get(ref)
+	if m == nil {
+		return false
+	}
+	_, ok := m.Get(lastSegment(ref))
+	return ok
+}
+
+func lastSegment(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+func prefixReference(ref string, prefix string) string {
+	name := lastSegment(ref)
+	return strings.TrimSuffix(ref, name) + prefix + name
+}
+
+func unprefixReference(ref string, prefix string) string {
+	if !strings.HasPrefix(ref, "#/components/") {
+		return ref
+	}
+	name := lastSegment(ref)
+	if n, ok := strings.CutPrefix(name, prefix); ok {
+		return strings.TrimSuffix(ref, name) + n
+	}
+	return ref
+}
+
+// nodeEqual compares two nodes ignoring mapping key order and the prefix
+// of each side's local component references.
+func nodeEqual(a, b *yaml.Node, aprefix, bprefix string) bool {
+	for a != nil && a.Kind == yaml.AliasNode {
+		a = a.Alias
+	}
+	for b != nil && b.Kind == yaml.AliasNode {
+		b = b.Alias
+	}
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Kind != b.Kind {
+		return false
+	}
+
+	switch a.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		if len(a.Content) != len(b.Content) {
+			return false
+		}
+		for i := range a.Content {
+			if !nodeEqual(a.Content[i], b.Content[i], aprefix, bprefix) {
+				return false
+			}
+		}
+		return true
+
+	case yaml.MappingNode:
+		if len(a.Content) != len(b.Content) {
+			return false
+		}
+		bm := map[string]*yaml.Node{}
+		for i := 0; i+1 < len(b.Content); i += 2 {
+			bm[b.Content[i].Value] = b.Content[i+1]
+		}
+		for i := 0; i+1 < len(a.Content); i += 2 {
+			k, av := a.Content[i].Value, a.Content[i+1]
+			bv, ok := bm[k]
+			if !ok {
+				return false
+			}
+			if k == "$ref" && av.Kind == yaml.ScalarNode && bv.Kind == yaml.ScalarNode {
+				if unprefixReference(av.Value, aprefix) != unprefixReference(bv.Value, bprefix) {
+					return false
+				}
+				continue
+			}
+			if !nodeEqual(av, bv, aprefix, bprefix) {
+				return false
+			}
+		}
+		return true
+
+	default:
+		return a.ShortTag() == b.ShortTag() && a.Value == b.Value
+	}
+}
+
+func collectReferences(n *yaml.Node) (refs []string) {
+	walkReferences(n, map[*yaml.Node]struct{}{}, func(ref *yaml.Node) {
+		refs = append(refs, ref.Value)
+	})
+	return
+}
+
+// RewriteReferences replaces every `$ref` value found in refs with its mapped value.
+func RewriteReferences(n *yaml.Node, refs map[string]string) {
+	if len(refs) == 0 {
+		return
+	}
+	walkReferences(n, map[*yaml.Node]struct{}{}, func(ref *yaml.Node) {
+		if v, ok := refs[ref.Value]; ok {
+			ref.Value = v
+		}
+	})
+}
+
+func walkReferences(n *yaml.Node, visited map[*yaml.Node]struct{}, fn func(ref *yaml.Node)) {
+	if n == nil {
+		return
+	}
+	if _, ok := visited[n]; ok {
+		return
+	}
+	visited[n] = struct{}{}
+
+	if n.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == "$ref" && n.Content[i+1].Kind == yaml.ScalarNode {
+				fn(n.Content[i+1])
+			}
+		}
+	}
+	for _, c := range n.Content {
+		walkReferences(c, visited, fn)
+	}
+	walkReferences(n.Alias, visited, fn)
+}
@@ -0,0 +1,156 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel"
+	"github.com/pb33f/libopenapi/index"
+	"gopkg.in/yaml.v3"
+)
+
+// NewDocumentConfiguration returns the configuration used to load a spec
+// that may be split across local files relative to basePath.
+func NewDocumentConfiguration(basePath string) *datamodel.DocumentConfiguration {
+	return &datamodel.DocumentConfiguration{
+		BasePath:                basePath,
+		ExtractRefsSequentially: true,
+		Logger: slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
+			Level: slog.LevelWarn,
+		})),
+	}
+}
+
+// LoadOptions holds the settings shared by the packages loading specs.
+type LoadOptions struct {
+	// Logger replaces the default logger of the configuration when set.
+	Logger *slog.Logger
+	// BasePath is the directory relative references are resolved from.
+	BasePath string
+	// LocalFS replaces the local disk relative references are read from when set.
+	LocalFS fs.FS
+	// AllowRemoteReferences resolves references to remote files too.
+	AllowRemoteReferences bool
+}
+
+// DocumentConfiguration returns the configuration used to load a spec with o.
+func (o LoadOptions) DocumentConfiguration() *datamodel.DocumentConfiguration {
+	config := NewDocumentConfiguration(o.BasePath)
+	config.AllowRemoteReferences = o.AllowRemoteReferences
+	if o.Logger != nil {
+		config.Logger = o.Logger
+	}
+	if o.LocalFS != nil {
+		config.LocalFS = o.LocalFS
+	}
+	return config
+}
+
+// LoadDocument reads the spec at the given path and resolves all of its references.
+func LoadDocument(path string) (doc libopenapi.Document, err error) {
+	return LoadDocumentWithConfiguration(path, NewDocumentConfiguration(filepath.Dir(path)))
+}
+
+// LoadDocumentWithConfiguration reads the spec at the given path and resolves all of its references
+// using the given configuration.
+func LoadDocumentWithConfiguration(path string, config *datamodel.DocumentConfiguration) (doc libopenapi.Document, err error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("fail to read file: %w", err)
+	}
+
+	doc, err = libopenapi.NewDocumentWithConfiguration(b, config)
+	if err != nil {
+		return nil, fmt.Errorf("fail to load openapi spec: %w", err)
+	}
+	return
+}
+
+// LoadDocumentFS reads the spec at the given root path inside fsys and resolves all of its
+// references from fsys, using the given configuration for everything else, see LoadDocumentFunc.
+func LoadDocumentFS(fsys fs.FS, root string, config *datamodel.DocumentConfiguration) (doc libopenapi.Document, err error) {
+	return LoadDocumentFunc(func(name string) ([]byte, error) { return fs.ReadFile(fsys, name) }, root, config)
+}
+
+// LoadDocumentFunc reads the spec at the given slash-separated root path, together with every local file it
+// references, with read and resolves all of its references, using the given configuration for everything else.
+//
+// Only the files reachable from the root spec are read. They are handed to libopenapi as a rolodex file system
+// rooted at the directory `/`, which the BasePath of the configuration is set into, so that the files of the
+// returned document are located by their path prefixed with `/`. The model is built to report unresolvable
+// references with paths relative to the same root as the given one.
+func LoadDocumentFunc(read func(name string) ([]byte, error), root string, config *datamodel.DocumentConfiguration) (doc libopenapi.Document, err error) {
+	root = path.Clean(root)
+	files := memFS{}
+	if err = readSpecFiles(read, root, files); err != nil {
+		return nil, fmt.Errorf("fail to read file: %w", err)
+	}
+
+	base, err := filepath.Abs(string(filepath.Separator))
+	if err != nil {
+		return nil, fmt.Errorf("fail to determine root directory: %w", err)
+	}
+	// the root spec is parsed by libopenapi itself, a copy of it in the file system would be indexed separately
+	b := files[root]
+	delete(files, root)
+	localFS, err := index.NewLocalFSWithConfig(&index.LocalFSConfig{BaseDirectory: base, DirFS: files, Logger: config.Logger})
+	if err != nil {
+		return nil, fmt.Errorf("fail to index spec files: %w", err)
+	}
+	config.BasePath = filepath.Join(base, filepath.FromSlash(path.Dir(root)))
+	config.LocalFS = localFS
+	doc, err = libopenapi.NewDocumentWithConfiguration(b, config)
+	if err != nil {
+		return nil, fmt.Errorf("fail to load openapi spec: %w", err)
+	}
+
+	_, err = BuildV3Model(doc)
+	if err != nil {
+		return nil, fmt.Errorf("fail to build openapi spec: %w", RelocateReferenceErrors(err, doc.GetRolodex(), root))
+	}
+	return
+}
+
+var errOutsideRoot = errors.New("points outside of the root directory")
+
+// readSpecFiles reads file and every local file it references, transitively, into files.
+// Only the error of reading file and the references pointing outside of the file system are returned:
+// libopenapi reports the references pointing to the other files that can not be read.
+func readSpecFiles(read func(name string) ([]byte, error), file string, files memFS) (err error) {
+	if _, ok := files[file]; ok {
+		return nil
+	}
+	if !fs.ValidPath(file) {
+		return &fs.PathError{Op: "read", Path: file, Err: fs.ErrInvalid}
+	}
+	b, err := read(file)
+	if err != nil {
+		return err
+	}
+	files[file] = b
+
+	var doc yaml.Node
+	if yaml.Unmarshal(b, &doc) != nil {
+		return nil
+	}
+	WalkRefs(&doc, func(ref *yaml.Node) {
+		target, _, ok := ResolveRef(file, ref.Value)
+		switch {
+		case !ok || err != nil:
+		case target == ".." || strings.HasPrefix(target, "../"):
+			err = fmt.Errorf("$ref '%s' in '%s' %w", ref.Value, file, errOutsideRoot)
+		default:
+			if rerr := readSpecFiles(read, target, files); errors.Is(rerr, errOutsideRoot) {
+				err = rerr
+			}
+		}
+	})
+	return err
+}
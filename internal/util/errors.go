@@ -0,0 +1,261 @@
+package util
+
+import (
+	"errors"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/libopenapi"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/index"
+	"github.com/telkomindonesia/openapi-utils/pkg/reference"
+	"gopkg.in/yaml.v3"
+)
+
+// NewReferenceError creates a reference.Error for the `$ref` held by node inside the file indexed by idx.
+func NewReferenceError(idx *index.SpecIndex, node *yaml.Node, err error) *reference.Error {
+	e := &reference.Error{Err: err}
+	if idx != nil {
+		e.File = idx.GetSpecAbsolutePath()
+		e.Pointer = PointerTo(idx.GetRootNode(), node)
+	}
+	if node != nil {
+		e.Line, e.Column = node.Line, node.Column
+		e.Ref = refValue(node)
+	}
+	return e
+}
+
+// RelocateReferenceErrors rewrites the absolute file paths assigned by the rolodex to the reference.Errors
+// wrapped by err, if any, into paths relative to the directory of specPath, and the path of the root
+// document into specPath itself. It returns err and must be called before err is wrapped into a formatted message.
+func RelocateReferenceErrors(err error, rolodex *index.Rolodex, specPath string) error {
+	var errs reference.Errors
+	if !errors.As(err, &errs) || rolodex == nil || rolodex.GetRootIndex() == nil || specPath == "" {
+		return err
+	}
+	root := rolodex.GetRootIndex().GetSpecAbsolutePath()
+	for _, e := range errs {
+		switch {
+		case e.File == "" || strings.HasPrefix(e.File, "http"):
+		case e.File == root:
+			e.File = specPath
+		default:
+			rel, err := filepath.Rel(filepath.Dir(root), e.File)
+			if err == nil {
+				e.File = filepath.Join(filepath.Dir(specPath), rel)
+			}
+		}
+	}
+	errs.Sort()
+	return err
+}
+
+// CollectReferenceErrors returns the unresolvable references found while indexing every file of the rolodex.
+func CollectReferenceErrors(rolodex *index.Rolodex) (errs reference.Errors) {
+	if rolodex == nil {
+		return
+	}
+	indexes := rolodex.GetIndexes()
+	if rolodex.GetRootIndex() != nil {
+		indexes = append(indexes, rolodex.GetRootIndex())
+	}
+
+	// the resolver of the root index also reports broken references found in the other files,
+	// using a different copy of their nodes, hence the errors are matched by position and target.
+	type key struct {
+		line, column int
+		path         string
+	}
+	seen := map[key]struct{}{}
+	for _, idx := range indexes {
+		for _, err := range idx.GetReferenceIndexErrors() {
+			ie, ok := err.(*index.IndexingError)
+			if !ok || ie.Node == nil {
+				continue
+			}
+			k := key{ie.Node.Line, ie.Node.Column, ie.Path}
+			if _, ok := seen[k]; ok {
+				continue
+			}
+			seen[k] = struct{}{}
+			errs = append(errs, NewReferenceError(idx, ie.Node, ie.Err))
+		}
+	}
+	for _, idx := range indexes {
+		if idx.GetResolver() == nil {
+			continue
+		}
+		for _, re := range idx.GetResolver().GetResolvingErrors() {
+			if re.Node == nil || re.CircularReference != nil {
+				continue
+			}
+			k := key{re.Node.Line, re.Node.Column, re.Path}
+			if _, ok := seen[k]; ok {
+				continue
+			}
+			seen[k] = struct{}{}
+			errs = append(errs, NewReferenceError(findIndex(indexes, re.Node), re.Node, re.ErrorRef))
+		}
+	}
+	errs.Sort()
+	return
+}
+
+func findIndex(indexes []*index.SpecIndex, node *yaml.Node) *index.SpecIndex {
+	for _, idx := range indexes {
+		if PointerTo(idx.GetRootNode(), node) != "" {
+			return idx
+		}
+	}
+	return nil
+}
+
+func refValue(node *yaml.Node) string {
+	if node.Kind != yaml.MappingNode {
+		return ""
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "$ref" {
+			return node.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+// PointerTo returns the JSON pointer of target inside the tree of root, or an empty string when
+// target is not part of it.
+func PointerTo(root *yaml.Node, target *yaml.Node) string {
+	if root == nil || target == nil {
+		return ""
+	}
+	var walk func(n *yaml.Node, p string) (string, bool)
+	walk = func(n *yaml.Node, p string) (string, bool) {
+		if n == target {
+			return p, true
+		}
+		switch n.Kind {
+		case yaml.DocumentNode:
+			for _, c := range n.Content {
+				if r, ok := walk(c, p); ok {
+					return r, true
+				}
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				if r, ok := walk(n.Content[i+1], p+"/"+EscapePointer(n.Content[i].Value)); ok {
+					return r, true
+				}
+			}
+		case yaml.SequenceNode:
+			for i, c := range n.Content {
+				if r, ok := walk(c, p+"/"+strconv.Itoa(i)); ok {
+					return r, true
+				}
+			}
+		}
+		return "", false
+	}
+	p, ok := walk(root, "#")
+	if !ok {
+		return ""
+	}
+	return p
+}
+
+// EscapePointer escapes s to be used as a token of a JSON pointer.
+func EscapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+// BuildV3Model builds the v3 model of doc, reporting unresolvable references as reference.Errors.
+func BuildV3Model(doc libopenapi.Document) (*libopenapi.DocumentModel[v3.Document], error) {
+	docv3, errs := doc.BuildV3Model()
+	if len(errs) == 0 {
+		return docv3, nil
+	}
+	if rerrs := CollectReferenceErrors(doc.GetRolodex()); len(rerrs) > 0 {
+		return nil, rerrs
+	}
+	return nil, errors.Join(errs...)
+}
+
+// DanglingReferences returns every `$ref` of the document rooted at root that points outside of
+// the document or to a location the document does not have.
+func DanglingReferences(root *yaml.Node) (errs reference.Errors) {
+	var walk func(n *yaml.Node, p string)
+	walk = func(n *yaml.Node, p string) {
+		switch n.Kind {
+		case yaml.DocumentNode:
+			for _, c := range n.Content {
+				walk(c, p)
+			}
+		case yaml.SequenceNode:
+			for i, c := range n.Content {
+				walk(c, p+"/"+strconv.Itoa(i))
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				k, v := n.Content[i], n.Content[i+1]
+				if k.Value == "$ref" && v.Kind == yaml.ScalarNode {
+					if err := checkLocalReference(root, v.Value); err != nil {
+						errs = append(errs, &reference.Error{Line: n.Line, Column: n.Column, Pointer: p, Ref: v.Value, Err: err})
+					}
+					continue
+				}
+				walk(v, p+"/"+EscapePointer(k.Value))
+			}
+		}
+	}
+	walk(root, "#")
+	return
+}
+
+func checkLocalReference(root *yaml.Node, ref string) error {
+	if !strings.HasPrefix(ref, "#") {
+		return errors.New("reference points outside of the document")
+	}
+	if LookupPointer(root, strings.TrimPrefix(ref, "#")) == nil {
+		return errors.New("reference points to a location that does not exist")
+	}
+	return nil
+}
+
+// LookupPointer returns the node located by the JSON pointer inside the document rooted at root, if any.
+func LookupPointer(root *yaml.Node, pointer string) *yaml.Node {
+	n := root
+	if n.Kind == yaml.DocumentNode && len(n.Content) > 0 {
+		n = n.Content[0]
+	}
+	if pointer == "" {
+		return n
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if t, err := url.PathUnescape(token); err == nil {
+			token = t
+		}
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+
+		var next *yaml.Node
+		switch n.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				if n.Content[i].Value == token {
+					next = n.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(token); err == nil && i >= 0 && i < len(n.Content) {
+				next = n.Content[i]
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		n = next
+	}
+	return n
+}
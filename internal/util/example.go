@@ -0,0 +1,374 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"gopkg.in/yaml.v3"
+)
+
+// exampleDepth bounds the recursion of GenerateExample through nested and circular schemas.
+const exampleDepth = 8
+
+// GenerateExample returns a value for the schema: its `example`, `default`, `const` or first `enum` value
+// when it has one, a value built from its type and constraints otherwise. Strings honour `format`, `pattern`
+// and the length bounds, numbers the bounds and `multipleOf`, and plain strings are given realistic values
+// based on the name of the property holding them. The value only holds types encoding/json renders.
+func GenerateExample(schema *base.Schema) any {
+	v, _ := generateExample(schema, "", 0)
+	return v
+}
+
+func generateExample(s *base.Schema, name string, depth int) (v any, ok bool) {
+	if s == nil || depth > exampleDepth {
+		return nil, false
+	}
+	for _, n := range []*yaml.Node{s.Example, s.Default, s.Const} {
+		if n == nil {
+			continue
+		}
+		if v, err := DecodeNode(n); err == nil {
+			return v, true
+		}
+	}
+	for _, nodes := range [][]*yaml.Node{s.Examples, s.Enum} {
+		if len(nodes) == 0 {
+			continue
+		}
+		if v, err := DecodeNode(nodes[0]); err == nil {
+			return v, true
+		}
+	}
+
+	switch {
+	case len(s.AllOf) > 0:
+		return generateAllOf(s, name, depth)
+	case len(s.OneOf) > 0:
+		return generateExample(s.OneOf[0].Schema(), name, depth+1)
+	case len(s.AnyOf) > 0:
+		return generateExample(s.AnyOf[0].Schema(), name, depth+1)
+	}
+
+	switch SchemaType(s) {
+	case "object":
+		return generateObject(s, depth), true
+	case "array":
+		return generateArray(s, name, depth), true
+	case "string":
+		return generateString(s, name), true
+	case "integer":
+		return int64(generateNumber(s, true)), true
+	case "number":
+		return generateNumber(s, false), true
+	case "boolean":
+		return true, true
+	}
+	return nil, SchemaType(s) == "null"
+}
+
+// generateAllOf merges the objects generated for every schema of `allOf` with the properties of s.
+func generateAllOf(s *base.Schema, name string, depth int) (any, bool) {
+	merged := map[string]any{}
+	for _, sp := range s.AllOf {
+		v, ok := generateExample(sp.Schema(), name, depth+1)
+		if !ok {
+			continue
+		}
+		m, isObject := v.(map[string]any)
+		if !isObject {
+			return v, true
+		}
+		for k, v := range m {
+			merged[k] = v
+		}
+	}
+	for k, v := range generateObject(s, depth) {
+		merged[k] = v
+	}
+	return merged, true
+}
+
+// generateObject generates every property of s, leaving out the optional ones that can not be generated.
+// Objects only declaring `additionalProperties` get a single entry.
+func generateObject(s *base.Schema, depth int) map[string]any {
+	m := map[string]any{}
+	for p := range orderedmap.Iterate(context.Background(), s.Properties) {
+		v, ok := generateExample(p.Value().Schema(), p.Key(), depth+1)
+		if !ok && !contains(s.Required, p.Key()) {
+			continue
+		}
+		m[p.Key()] = v
+	}
+	if len(m) == 0 && s.AdditionalProperties != nil && s.AdditionalProperties.IsA() && s.AdditionalProperties.A != nil {
+		if v, ok := generateExample(s.AdditionalProperties.A.Schema(), "", depth+1); ok {
+			m["key"] = v
+		}
+	}
+	return m
+}
+
+func generateArray(s *base.Schema, name string, depth int) []any {
+	a := []any{}
+	if s.Items == nil || !s.Items.IsA() || s.Items.A == nil {
+		return a
+	}
+	n := 1
+	if s.MinItems != nil && *s.MinItems > 1 {
+		n = int(*s.MinItems)
+	}
+	if s.MaxItems != nil && int64(n) > *s.MaxItems {
+		n = int(*s.MaxItems)
+	}
+	for i := 0; i < n; i++ {
+		v, ok := generateExample(s.Items.A.Schema(), name, depth+1)
+		if !ok {
+			break
+		}
+		a = append(a, v)
+	}
+	return a
+}
+
+// generateNumber returns the value closest to zero within the bounds of s, rounded to its `multipleOf`.
+func generateNumber(s *base.Schema, integer bool) float64 {
+	step := 1.0
+	if !integer {
+		step = 0.5
+	}
+	lo, hi := math.Inf(-1), math.Inf(1)
+	if s.Minimum != nil {
+		lo = *s.Minimum
+		if s.ExclusiveMinimum != nil && s.ExclusiveMinimum.IsA() && s.ExclusiveMinimum.A {
+			lo += step
+		}
+	}
+	if s.ExclusiveMinimum != nil && s.ExclusiveMinimum.IsB() {
+		lo = math.Max(lo, s.ExclusiveMinimum.B+step)
+	}
+	if s.Maximum != nil {
+		hi = *s.Maximum
+		if s.ExclusiveMaximum != nil && s.ExclusiveMaximum.IsA() && s.ExclusiveMaximum.A {
+			hi -= step
+		}
+	}
+	if s.ExclusiveMaximum != nil && s.ExclusiveMaximum.IsB() {
+		hi = math.Min(hi, s.ExclusiveMaximum.B-step)
+	}
+	if integer {
+		lo, hi = math.Ceil(lo), math.Floor(hi)
+	}
+
+	v := math.Min(math.Max(0, lo), hi)
+	if s.MultipleOf != nil && *s.MultipleOf > 0 {
+		m := *s.MultipleOf
+		if v = math.Ceil(v/m) * m; v > hi {
+			v = math.Floor(hi/m) * m
+		}
+	}
+	if math.IsInf(v, 0) {
+		return 0
+	}
+	return v
+}
+
+// formatExamples holds the values generated for the string formats.
+var formatExamples = map[string]string{
+	"date-time": "2024-01-01T00:00:00Z",
+	"date":      "2024-01-01",
+	"time":      "12:00:00",
+	"uuid":      "3fa85f64-5717-4562-b3fc-2c963f66afa6",
+	"email":     "user@example.com",
+	"uri":       "https://example.com",
+	"url":       "https://example.com",
+	"hostname":  "example.com",
+	"ipv4":      "192.0.2.1",
+	"ipv6":      "2001:db8::1",
+	"byte":      "c3RyaW5n",
+	"password":  "P@ssw0rd",
+}
+
+// nameExamples holds the values generated for plain strings held by properties with the given names, compared
+// case-insensitively and ignoring `-` and `_`. The names marked as suffix also match the names ending with them.
+var nameExamples = []struct {
+	name   string
+	suffix bool
+	value  string
+}{
+	{"email", true, "jane.doe@example.com"},
+	{"phone", true, "+6281234567890"},
+	{"phonenumber", true, "+6281234567890"},
+	{"url", true, "https://example.com"},
+	{"name", false, "Jane Doe"},
+	{"fullname", false, "Jane Doe"},
+	{"firstname", false, "Jane"},
+	{"lastname", false, "Doe"},
+	{"username", false, "janedoe"},
+	{"city", false, "Jakarta"},
+	{"country", false, "Indonesia"},
+	{"countrycode", false, "ID"},
+	{"currency", false, "IDR"},
+	{"address", false, "Jl. Gatot Subroto Kav. 52"},
+	{"description", false, "Lorem ipsum dolor sit amet."},
+}
+
+func generateString(s *base.Schema, name string) string {
+	if v, ok := formatExamples[s.Format]; ok {
+		return v
+	}
+	if s.Pattern != "" {
+		if v, ok := generatePattern(s.Pattern); ok {
+			return v
+		}
+	}
+
+	v := "string"
+	key := strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(name))
+	for _, e := range nameExamples {
+		if key == e.name || (e.suffix && strings.HasSuffix(key, e.name)) {
+			v = e.value
+			break
+		}
+	}
+	r := []rune(v)
+	for s.MinLength != nil && int64(len(r)) < *s.MinLength {
+		r = append(r, r[len(r)%len([]rune(v))])
+	}
+	if s.MaxLength != nil && int64(len(r)) > *s.MaxLength {
+		r = r[:*s.MaxLength]
+	}
+	return string(r)
+}
+
+// generatePattern returns a string matching the regular expression, taking the shortest allowed repetitions
+// and the first alternatives, or false when none could be built.
+func generatePattern(pattern string) (string, bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+	b := strings.Builder{}
+	writePattern(&b, re.Simplify())
+	v := b.String()
+	if m, err := regexp.MatchString(pattern, v); err != nil || !m {
+		return "", false
+	}
+	return v, true
+}
+
+func writePattern(b *strings.Builder, re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		b.WriteRune(classRune(re.Rune))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteRune('a')
+	case syntax.OpCapture, syntax.OpPlus:
+		writePattern(b, re.Sub[0])
+	case syntax.OpRepeat:
+		for i := 0; i < re.Min; i++ {
+			writePattern(b, re.Sub[0])
+		}
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			writePattern(b, sub)
+		}
+	case syntax.OpAlternate:
+		writePattern(b, re.Sub[0])
+	}
+}
+
+// classRune returns a letter or a digit of the character class given as pairs of rune ranges when it has one,
+// its first rune otherwise.
+func classRune(ranges []rune) rune {
+	for _, c := range "aA0" {
+		for i := 0; i+1 < len(ranges); i += 2 {
+			if ranges[i] <= c && c <= ranges[i+1] {
+				return c
+			}
+		}
+	}
+	for i := 0; i+1 < len(ranges); i += 2 {
+		for c := ranges[i]; c <= ranges[i+1]; c++ {
+			if unicode.IsPrint(c) {
+				return c
+			}
+		}
+	}
+	return 'a'
+}
+
+// SchemaType returns the type of s other than `null`, deducing it from the keywords s uses when it has none.
+func SchemaType(s *base.Schema) string {
+	for _, t := range s.Type {
+		if t != "null" {
+			return t
+		}
+	}
+	switch {
+	case s.Properties != nil && s.Properties.Len() > 0, s.AdditionalProperties != nil:
+		return "object"
+	case s.Items != nil:
+		return "array"
+	case len(s.Type) > 0:
+		return "null"
+	}
+	return ""
+}
+
+// DecodeNode decodes n into a value holding only types encoding/json renders.
+// Timestamps are kept as the strings they are written as.
+func DecodeNode(n *yaml.Node) (v any, err error) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil, nil
+		}
+		return DecodeNode(n.Content[0])
+	case yaml.AliasNode:
+		return DecodeNode(n.Alias)
+	case yaml.MappingNode:
+		m := make(map[string]any, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if m[n.Content[i].Value], err = DecodeNode(n.Content[i+1]); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case yaml.SequenceNode:
+		a := make([]any, 0, len(n.Content))
+		for _, c := range n.Content {
+			v, err := DecodeNode(c)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		return a, nil
+	}
+
+	switch n.ShortTag() {
+	case "!!null", "!!bool", "!!int", "!!float":
+		if err = n.Decode(&v); err != nil {
+			return nil, fmt.Errorf("fail to decode value at line %d: %w", n.Line, err)
+		}
+		return v, nil
+	}
+	return n.Value, nil
+}
+
+func contains(values []string, v string) bool {
+	for _, e := range values {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
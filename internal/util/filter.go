@@ -0,0 +1,190 @@
+package util
+
+import (
+	"context"
+	"strings"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"gopkg.in/yaml.v3"
+)
+
+// DeleteOperations deletes every operation of docv3 for which keep returns false,
+// together with the path items left without any operation. It returns the tags of the deleted operations.
+func DeleteOperations(ctx context.Context, docv3 *v3.Document, keep func(path string, method string, pathItem *v3.PathItem, op *v3.Operation) bool) (tags map[string]struct{}) {
+	tags = map[string]struct{}{}
+	if docv3.Paths == nil {
+		return
+	}
+
+	var empty []string
+	for m := range orderedmap.Iterate(ctx, docv3.Paths.PathItems) {
+		pathItem := m.Value()
+		ops := GetOperationsMap(pathItem)
+		for method, op := range ops {
+			if keep(m.Key(), method, pathItem, op) {
+				continue
+			}
+			for _, tag := range op.Tags {
+				tags[tag] = struct{}{}
+			}
+			SetOperation(pathItem, method, nil)
+			delete(ops, method)
+		}
+		if len(ops) == 0 {
+			empty = append(empty, m.Key())
+		}
+	}
+	for _, path := range empty {
+		docv3.Paths.PathItems.Delete(path)
+	}
+	return
+}
+
+// PruneComponents removes the components of the document rooted at root that can not be reached through
+// a local reference from outside of `components`. Security schemes are kept since they are referred by name,
+// so are the extensions of `components`. The top-level tags among deletedTags, as returned by DeleteOperations,
+// that no remaining operation uses are removed too.
+func PruneComponents(root *yaml.Node, deletedTags map[string]struct{}) {
+	doc := root
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		doc = doc.Content[0]
+	}
+	if doc.Kind != yaml.MappingNode {
+		return
+	}
+	pruneTags(doc, deletedTags)
+
+	var components *yaml.Node
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		if doc.Content[i].Value == "components" {
+			components = doc.Content[i+1]
+		}
+	}
+	if components == nil {
+		return
+	}
+
+	reachable := map[string]struct{}{}
+	var queue []*yaml.Node
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		if n.Kind == yaml.ScalarNode {
+			name, ok := componentOf(n.Value)
+			if !ok {
+				return
+			}
+			if _, ok := reachable[name]; ok {
+				return
+			}
+			reachable[name] = struct{}{}
+			if c := LookupPointer(root, name); c != nil {
+				queue = append(queue, c)
+			}
+			return
+		}
+		for _, c := range n.Content {
+			walk(c)
+		}
+	}
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		if doc.Content[i].Value != "components" {
+			walk(doc.Content[i+1])
+		}
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		walk(n)
+	}
+
+	content := components.Content[:0]
+	for i := 0; i+1 < len(components.Content); i += 2 {
+		k, v := components.Content[i], components.Content[i+1]
+		if k.Value == "securitySchemes" || strings.HasPrefix(k.Value, "x-") || v.Kind != yaml.MappingNode {
+			content = append(content, k, v)
+			continue
+		}
+
+		kept := v.Content[:0]
+		for j := 0; j+1 < len(v.Content); j += 2 {
+			if _, ok := reachable["/components/"+EscapePointer(k.Value)+"/"+EscapePointer(v.Content[j].Value)]; ok {
+				kept = append(kept, v.Content[j], v.Content[j+1])
+			}
+		}
+		v.Content = kept
+		if len(kept) > 0 {
+			content = append(content, k, v)
+		}
+	}
+	components.Content = content
+}
+
+// pruneTags removes the top-level tags of the document mapping doc that are among deletedTags
+// and that no operation uses.
+func pruneTags(doc *yaml.Node, deletedTags map[string]struct{}) {
+	var tags, paths *yaml.Node
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		switch doc.Content[i].Value {
+		case "tags":
+			tags = doc.Content[i+1]
+		case "paths":
+			paths = doc.Content[i+1]
+		}
+	}
+	if tags == nil || tags.Kind != yaml.SequenceNode || len(deletedTags) == 0 {
+		return
+	}
+
+	used := map[string]struct{}{}
+	if paths != nil {
+		for i := 1; i < len(paths.Content); i += 2 {
+			pathItem := paths.Content[i]
+			for j := 1; j < len(pathItem.Content); j += 2 {
+				if op := mappingValue(pathItem.Content[j], "tags"); op != nil {
+					for _, tag := range op.Content {
+						used[tag.Value] = struct{}{}
+					}
+				}
+			}
+		}
+	}
+
+	kept := tags.Content[:0]
+	for _, tag := range tags.Content {
+		name := mappingValue(tag, "name")
+		if name != nil {
+			_, deleted := deletedTags[name.Value]
+			if _, ok := used[name.Value]; deleted && !ok {
+				continue
+			}
+		}
+		kept = append(kept, tag)
+	}
+	tags.Content = kept
+}
+
+// mappingValue returns the value of the given key of the mapping n, or nil.
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// componentOf returns the JSON pointer of the component a local reference points into.
+func componentOf(ref string) (string, bool) {
+	if !strings.HasPrefix(ref, "#/components/") {
+		return "", false
+	}
+	tokens := strings.SplitN(strings.TrimPrefix(ref, "#/"), "/", 4)
+	if len(tokens) < 3 {
+		return "", false
+	}
+	return "/" + strings.Join(tokens[:3], "/"), true
+}
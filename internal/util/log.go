@@ -0,0 +1,87 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// LogRecorder keeps the records logged at or above a level while passing every record to the wrapped logger.
+// libopenapi reports some failures only through its logger, the recorder allows them to be turned into errors.
+type LogRecorder struct {
+	level slog.Level
+	next  slog.Handler
+
+	mu      sync.Mutex
+	records []string
+}
+
+func NewLogRecorder(logger *slog.Logger, level slog.Level) *LogRecorder {
+	return &LogRecorder{level: level, next: logger.Handler()}
+}
+
+// Logger returns the logger whose records are kept by the recorder.
+func (r *LogRecorder) Logger() *slog.Logger {
+	return slog.New(recordingHandler{recorder: r, next: r.next})
+}
+
+// Errors returns an error for each record kept so far.
+func (r *LogRecorder) Errors() (errs []error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.records {
+		errs = append(errs, errors.New(s))
+	}
+	return
+}
+
+func (r *LogRecorder) record(rec slog.Record, attrs []slog.Attr) {
+	b := strings.Builder{}
+	b.WriteString(rec.Message)
+	write := func(a slog.Attr) bool {
+		b.WriteString(" " + a.Key + "=" + a.Value.String())
+		return true
+	}
+	for _, a := range attrs {
+		write(a)
+	}
+	rec.Attrs(write)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, b.String())
+}
+
+type recordingHandler struct {
+	recorder *LogRecorder
+	next     slog.Handler
+	attrs    []slog.Attr
+}
+
+func (h recordingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.recorder.level || h.next.Enabled(ctx, level)
+}
+
+func (h recordingHandler) Handle(ctx context.Context, rec slog.Record) error {
+	if rec.Level >= h.recorder.level {
+		h.recorder.record(rec, h.attrs)
+	}
+	if !h.next.Enabled(ctx, rec.Level) {
+		return nil
+	}
+	return h.next.Handle(ctx, rec)
+}
+
+func (h recordingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return recordingHandler{
+		recorder: h.recorder,
+		next:     h.next.WithAttrs(attrs),
+		attrs:    append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...),
+	}
+}
+
+func (h recordingHandler) WithGroup(name string) slog.Handler {
+	return recordingHandler{recorder: h.recorder, next: h.next.WithGroup(name), attrs: h.attrs}
+}
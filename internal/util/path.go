@@ -6,6 +6,9 @@ import (
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
 )
 
+// Methods lists the methods of the operations of a path item in the order the specification declares them.
+var Methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
 func GetOperationsMap(p *v3.PathItem) (ops map[string]*v3.Operation) {
 	ops = map[string]*v3.Operation{}
 	if p.Get != nil {
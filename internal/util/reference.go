@@ -1,10 +1,12 @@
 package util
 
 import (
+	"path"
 	"strings"
 
 	"github.com/pb33f/libopenapi/datamodel/high/base"
 	"github.com/pb33f/libopenapi/index"
+	"gopkg.in/yaml.v3"
 )
 
 func LocalizeReference(ref *index.Reference, prefix string) {
@@ -12,3 +14,36 @@ func LocalizeReference(ref *index.Reference, prefix string) {
 	refdef := strings.TrimSuffix(ref.Definition, ref.Name) + name
 	ref.Node.Content = base.CreateSchemaProxyRef(refdef).GetReferenceNode().Content
 }
+
+// ResolveRef returns the slash-separated path of the local file a reference written in file points to,
+// together with the JSON pointer inside it.
+func ResolveRef(file string, ref string) (target string, pointer string, ok bool) {
+	target, pointer, _ = strings.Cut(ref, "#")
+	switch {
+	case strings.Contains(target, "://"):
+		return "", "", false
+	case target == "":
+		target = file
+	default:
+		target = path.Join(path.Dir(file), target)
+	}
+	return target, pointer, true
+}
+
+// WalkRefs calls fn with the value of every `$ref` found under n.
+func WalkRefs(n *yaml.Node, fn func(ref *yaml.Node)) {
+	switch n.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, c := range n.Content {
+			WalkRefs(c, fn)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == "$ref" && n.Content[i+1].Kind == yaml.ScalarNode {
+				fn(n.Content[i+1])
+				continue
+			}
+			WalkRefs(n.Content[i+1], fn)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package util
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/pb33f/libopenapi/index"
+)
+
+// setSource records where the component copied from src comes from, rootPath being the absolute path of the root spec.
+func (c StubComponents) setSource(src *index.Reference, prefix string, rootPath string) {
+	kind, _, ok := strings.Cut(strings.TrimPrefix(src.Definition, "#/components/"), "/")
+	if !ok || !strings.HasPrefix(src.Definition, "#/components/") {
+		return
+	}
+
+	file, pointer, _ := strings.Cut(src.FullDefinition, "#")
+	if file == "" {
+		file = rootPath
+	}
+	if rel, err := filepath.Rel(filepath.Dir(rootPath), file); err == nil && !strings.HasPrefix(file, "http") {
+		file = filepath.ToSlash(rel)
+	}
+	c.Sources.Set("/components/"+kind+"/"+prefix+src.Name, file+"#"+pointer)
+}
@@ -2,7 +2,6 @@ package util
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"strings"
 
@@ -13,6 +12,7 @@ import (
 	"github.com/pb33f/libopenapi/index"
 	"github.com/pb33f/libopenapi/orderedmap"
 	"github.com/pb33f/libopenapi/utils"
+	"github.com/telkomindonesia/openapi-utils/pkg/reference"
 	"gopkg.in/yaml.v3"
 )
 
@@ -27,6 +27,10 @@ type StubComponents struct {
 	Links           *orderedmap.Map[string, *yaml.Node] `json:"links,omitempty" yaml:"links,omitempty"`
 	Callbacks       *orderedmap.Map[string, *yaml.Node] `json:"callbacks,omitempty" yaml:"callbacks,omitempty"`
 	Extensions      *orderedmap.Map[string, *yaml.Node] `json:"-" yaml:"-"`
+	// Sources holds the location every component was copied from, as a reference made of the slash-separated
+	// path of its file relative to the directory of the root spec and its JSON pointer inside it,
+	// keyed by its JSON pointer inside the bundled spec.
+	Sources *orderedmap.Map[string, string] `json:"-" yaml:"-"`
 }
 
 func NewStubComponents() (c StubComponents) {
@@ -41,6 +45,7 @@ func NewStubComponents() (c StubComponents) {
 		Links:           orderedmap.New[string, *yaml.Node](),
 		Callbacks:       orderedmap.New[string, *yaml.Node](),
 		Extensions:      orderedmap.New[string, *yaml.Node](),
+		Sources:         orderedmap.New[string, string](),
 	}
 	return
 }
@@ -54,6 +59,14 @@ func (c StubComponents) CopyComponents(docv3 *libopenapi.DocumentModel[v3.Docume
 }
 
 func (c StubComponents) copyComponents(docv3 *libopenapi.DocumentModel[v3.Document], prefix string, localized bool) (err error) {
+	if localized {
+		// schemas are built lazily, render them once before their references get localized
+		if _, err = docv3.Model.Render(); err != nil {
+			return fmt.Errorf("fail to render doc: %w", err)
+		}
+	}
+
+	var errs reference.Errors
 	indexes := append(docv3.Index.GetRolodex().GetIndexes(), docv3.Index)
 	for _, idx := range indexes {
 		for _, ref := range idx.GetRawReferencesSequenced() {
@@ -67,8 +80,10 @@ func (c StubComponents) copyComponents(docv3 *libopenapi.DocumentModel[v3.Docume
 
 			err := c.copyComponentNode(ref, prefix)
 			if err != nil {
-				return fmt.Errorf("fail to locate component: %w", err)
+				errs = append(errs, NewReferenceError(ref.Index, ref.Node, err))
+				continue
 			}
+			c.setSource(ref, prefix, docv3.Index.GetSpecAbsolutePath())
 
 			if !localized {
 				continue
@@ -77,9 +92,15 @@ func (c StubComponents) copyComponents(docv3 *libopenapi.DocumentModel[v3.Docume
 			LocalizeReference(ref, prefix)
 		}
 	}
+	if len(errs) > 0 {
+		errs.Sort()
+		return errs
+	}
 
-	for m := range orderedmap.Iterate(context.Background(), docv3.Model.Components.Extensions) {
-		c.Extensions.Set(m.Key(), m.Value())
+	if docv3.Model.Components != nil {
+		for m := range orderedmap.Iterate(context.Background(), docv3.Model.Components.Extensions) {
+			c.Extensions.Set(m.Key(), m.Value())
+		}
 	}
 
 	if !localized {
@@ -89,6 +110,89 @@ func (c StubComponents) copyComponents(docv3 *libopenapi.DocumentModel[v3.Docume
 	return c.replaceRootNodes(docv3)
 }
 
+// CopyAndLocalizeReachableComponents copies, with the given prefix, every component reachable through a reference
+// from the given nodes of docv3, and localizes those references in place. Since rendered models reuse the nodes of
+// their references, docv3 renders with local references afterwards without having to be reloaded.
+func (c StubComponents) CopyAndLocalizeReachableComponents(docv3 *libopenapi.DocumentModel[v3.Document], prefix string, roots ...*yaml.Node) (err error) {
+	refs := map[*yaml.Node]*index.Reference{}
+	indexes := append(docv3.Index.GetRolodex().GetIndexes(), docv3.Index)
+	for _, idx := range indexes {
+		for _, ref := range idx.GetRawReferencesSequenced() {
+			refs[ref.Node] = ref
+		}
+	}
+
+	var errs reference.Errors
+	visited := map[*yaml.Node]struct{}{}
+	copied := map[string]struct{}{}
+	var queue []*yaml.Node
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		if n == nil {
+			return
+		}
+		if _, ok := visited[n]; ok {
+			return
+		}
+		visited[n] = struct{}{}
+
+		if ref, ok := refs[n]; ok {
+			// circular references are followed too, components are only copied once
+			if _, ok := copied[ref.FullDefinition]; !ok {
+				copied[ref.FullDefinition] = struct{}{}
+				target, err := locateNode(ref)
+				if err == nil {
+					err = c.copyComponentNode(ref, prefix)
+				}
+				if err != nil {
+					errs = append(errs, NewReferenceError(ref.Index, ref.Node, err))
+					return
+				}
+				c.setSource(ref, prefix, docv3.Index.GetSpecAbsolutePath())
+				queue = append(queue, target)
+			}
+			LocalizeReference(ref, prefix)
+			return
+		}
+		for _, child := range n.Content {
+			walk(child)
+		}
+		walk(n.Alias)
+	}
+	// components are copied in the order their references first appear in the rendered doc:
+	// the given nodes first, then the components they refer to, breadth first.
+	for _, root := range roots {
+		walk(root)
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		walk(n)
+	}
+	if len(errs) > 0 {
+		errs.Sort()
+		return errs
+	}
+	return
+}
+
+// CopySecuritySchemes copies all security schemes declared by the doc since they are
+// referred by name from security requirements instead of by `$ref`.
+func (c StubComponents) CopySecuritySchemes(docv3 *libopenapi.DocumentModel[v3.Document], prefix string) (err error) {
+	if docv3.Model.Components == nil {
+		return
+	}
+
+	for m := range orderedmap.Iterate(context.Background(), docv3.Model.Components.SecuritySchemes) {
+		n, err := m.Value().MarshalYAML()
+		if err != nil {
+			return fmt.Errorf("fail to render security scheme '%s': %w", m.Key(), err)
+		}
+		c.SecuritySchemes.Set(prefix+m.Key(), n.(*yaml.Node))
+	}
+	return
+}
+
 func (c StubComponents) copyComponentNode(src *index.Reference, prefix string) (err error) {
 	node, err := locateNode(src)
 	if err != nil {
@@ -129,6 +233,15 @@ func (c StubComponents) copyComponentNode(src *index.Reference, prefix string) (
 }
 
 func locateNode(ref *index.Reference) (node *yaml.Node, err error) {
+	// the index of the referencing file may hold a copy of the nodes of the referenced file, which
+	// is not the copy whose references are localized, hence the node is looked up in its own file first.
+	file, pointer, _ := strings.Cut(ref.FullDefinition, "#")
+	if idx := fileIndex(ref.Index, file); idx != nil && pointer != "" {
+		if r := idx.FindComponentInRoot("#" + pointer); r != nil && r.Node != nil {
+			return r.Node, nil
+		}
+	}
+
 	idx := ref.Index
 	if r := getFromMap(idx.GetAllComponentSchemas(), ref.Definition); r != nil {
 		return r.Node, nil
@@ -157,11 +270,24 @@ func locateNode(ref *index.Reference) (node *yaml.Node, err error) {
 
 	node, _, err = low.LocateRefNode(ref.Node, ref.Index)
 	if err != nil {
-		return nil, fmt.Errorf("fail to locate component: %w", err)
+		return nil, err
 	}
 	return
 }
 
+// fileIndex returns the index of the given file among the ones of the rolodex of idx, if any.
+func fileIndex(idx *index.SpecIndex, file string) *index.SpecIndex {
+	if idx == nil || idx.GetRolodex() == nil || file == "" {
+		return nil
+	}
+	for _, i := range idx.GetRolodex().GetIndexes() {
+		if i.GetSpecAbsolutePath() == file {
+			return i
+		}
+	}
+	return nil
+}
+
 func (c StubComponents) replaceRootNodes(docv3 *libopenapi.DocumentModel[v3.Document]) (err error) {
 	y, err := c.ToYamlNode()
 	if err != nil {
@@ -177,8 +303,8 @@ func (c StubComponents) replaceRootNodes(docv3 *libopenapi.DocumentModel[v3.Docu
 }
 
 func (c StubComponents) RenderAndReload(doc libopenapi.Document) (b []byte, ndoc libopenapi.Document, docv3 *libopenapi.DocumentModel[v3.Document], err error) {
-	docv3, errs := doc.BuildV3Model()
-	if err := errors.Join(errs...); err != nil {
+	docv3, err = BuildV3Model(doc)
+	if err != nil {
 		return nil, nil, nil, fmt.Errorf("fail to build v3 model: %w", err)
 	}
 	b, err = c.Render(docv3)
@@ -190,8 +316,8 @@ func (c StubComponents) RenderAndReload(doc libopenapi.Document) (b []byte, ndoc
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("fail to parse new doc: %w", err)
 	}
-	docv3, errs = ndoc.BuildV3Model()
-	if err := errors.Join(errs...); err != nil {
+	docv3, err = BuildV3Model(ndoc)
+	if err != nil {
 		return nil, nil, nil, fmt.Errorf("fail to build v3 model from new doc: %w", err)
 	}
 
@@ -199,6 +325,15 @@ func (c StubComponents) RenderAndReload(doc libopenapi.Document) (b []byte, ndoc
 }
 
 func (c StubComponents) Render(docv3 *libopenapi.DocumentModel[v3.Document]) ([]byte, error) {
+	root, err := c.RenderNode(docv3)
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(root)
+}
+
+func (c StubComponents) RenderNode(docv3 *libopenapi.DocumentModel[v3.Document]) (*yaml.Node, error) {
 	comp, err := c.ToYamlNode()
 	if err != nil {
 		return nil, fmt.Errorf("fail to encode stub-components to yaml: %w", err)
@@ -212,12 +347,12 @@ func (c StubComponents) Render(docv3 *libopenapi.DocumentModel[v3.Document]) ([]
 
 	_, rootComp := utils.FindKeyNode(v3low.ComponentsLabel, root.Content)
 	if rootComp == nil {
-		root.Content = append(root.Content, comp.Content...)
+		root.Content = append(root.Content, comp.Content[0].Content...)
 	} else {
 		rootComp.Content = comp.Content[0].Content[1].Content
 	}
 
-	return yaml.Marshal(root)
+	return root, nil
 }
 
 func (c StubComponents) ToYamlNode() (n *yaml.Node, err error) {
@@ -1,5 +1,10 @@
 package util
 
+import (
+	"strings"
+	"unicode"
+)
+
 func MapFirstEntry[K comparable, V any](m map[K]V) (e struct {
 	Key   K
 	Value V
@@ -22,3 +27,14 @@ func getFromMap[K comparable, V any](m map[K]V, key K) (v V) {
 	}
 	return m[key]
 }
+
+// Pascal joins the letters and digits of s into a PascalCase identifier.
+func Pascal(s string) string {
+	b := strings.Builder{}
+	for _, w := range strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		r := []rune(w)
+		b.WriteRune(unicode.ToUpper(r[0]))
+		b.WriteString(string(r[1:]))
+	}
+	return b.String()
+}
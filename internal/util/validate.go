@@ -0,0 +1,360 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaError is a violation of a schema by a value.
+type SchemaError struct {
+	// Pointer is the JSON pointer of the offending value inside the validated value.
+	Pointer string
+	Message string
+}
+
+func (e *SchemaError) Error() string {
+	if e.Pointer == "" {
+		return e.Message
+	}
+	return e.Pointer + ": " + e.Message
+}
+
+// SchemaErrors aggregates every SchemaError found while validating a single value.
+type SchemaErrors []*SchemaError
+
+func (errs SchemaErrors) Error() string {
+	s := make([]string, 0, len(errs))
+	for _, e := range errs {
+		s = append(s, e.Error())
+	}
+	return strings.Join(s, "\n")
+}
+
+func (errs SchemaErrors) Unwrap() []error {
+	u := make([]error, 0, len(errs))
+	for _, e := range errs {
+		u = append(u, e)
+	}
+	return u
+}
+
+// ValidateValue validates v against the schema and returns SchemaErrors holding every violation found.
+// v must only hold the types encoding/json decodes into, or the ones DecodeNode returns.
+func ValidateValue(schema *base.Schema, v any) error {
+	var errs SchemaErrors
+	validateValue(schema, v, "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateValue(s *base.Schema, v any, pointer string, errs *SchemaErrors) {
+	if s == nil {
+		return
+	}
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, &SchemaError{Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if v == nil {
+		if len(s.Type) > 0 && !contains(s.Type, "null") && (s.Nullable == nil || !*s.Nullable) {
+			fail("expected %s, got null", strings.Join(s.Type, " or "))
+		}
+		return
+	}
+	if len(s.Type) > 0 && !matchesAnyType(s.Type, v) {
+		fail("expected %s, got %s", strings.Join(s.Type, " or "), jsonType(v))
+		return
+	}
+	if len(s.Enum) > 0 && !matchesEnum(s.Enum, v) {
+		fail("value is not one of the allowed values")
+	}
+	if s.Const != nil {
+		if c, err := DecodeNode(s.Const); err == nil && !equalValues(c, v) {
+			fail("value is not equal to the constant")
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		validateObject(s, v, pointer, errs)
+	case []any:
+		validateArray(s, v, pointer, errs)
+	case string:
+		validateString(s, v, fail)
+	default:
+		if n, ok := toNumber(v); ok {
+			validateNumber(s, n, fail)
+		}
+	}
+
+	for _, sp := range s.AllOf {
+		validateValue(sp.Schema(), v, pointer, errs)
+	}
+	if len(s.AnyOf) > 0 && countMatches(s.AnyOf, v) == 0 {
+		fail("value does not match any schema of anyOf")
+	}
+	if len(s.OneOf) > 0 {
+		if n := countMatches(s.OneOf, v); n != 1 {
+			fail("value matches %d schemas of oneOf, expected exactly 1", n)
+		}
+	}
+	if s.Not != nil && countMatches([]*base.SchemaProxy{s.Not}, v) == 1 {
+		fail("value matches the schema of not")
+	}
+}
+
+func validateObject(s *base.Schema, v map[string]any, pointer string, errs *SchemaErrors) {
+	for _, name := range s.Required {
+		if _, ok := v[name]; !ok {
+			*errs = append(*errs, &SchemaError{Pointer: pointer, Message: fmt.Sprintf("missing required property '%s'", name)})
+		}
+	}
+
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		ptr := pointer + "/" + EscapePointer(k)
+		if s.Properties != nil {
+			if sp, ok := s.Properties.Get(k); ok {
+				validateValue(sp.Schema(), v[k], ptr, errs)
+				continue
+			}
+		}
+		switch ap := s.AdditionalProperties; {
+		case ap == nil:
+		case ap.IsA() && ap.A != nil:
+			validateValue(ap.A.Schema(), v[k], ptr, errs)
+		case ap.IsB() && !ap.B:
+			*errs = append(*errs, &SchemaError{Pointer: pointer, Message: fmt.Sprintf("property '%s' is not allowed", k)})
+		}
+	}
+
+	if s.MinProperties != nil && int64(len(v)) < *s.MinProperties {
+		*errs = append(*errs, &SchemaError{Pointer: pointer, Message: fmt.Sprintf("expected at least %d properties, got %d", *s.MinProperties, len(v))})
+	}
+	if s.MaxProperties != nil && int64(len(v)) > *s.MaxProperties {
+		*errs = append(*errs, &SchemaError{Pointer: pointer, Message: fmt.Sprintf("expected at most %d properties, got %d", *s.MaxProperties, len(v))})
+	}
+}
+
+func validateArray(s *base.Schema, v []any, pointer string, errs *SchemaErrors) {
+	if s.Items != nil && s.Items.IsA() && s.Items.A != nil {
+		for i, e := range v {
+			validateValue(s.Items.A.Schema(), e, pointer+"/"+strconv.Itoa(i), errs)
+		}
+	}
+	if s.MinItems != nil && int64(len(v)) < *s.MinItems {
+		*errs = append(*errs, &SchemaError{Pointer: pointer, Message: fmt.Sprintf("expected at least %d items, got %d", *s.MinItems, len(v))})
+	}
+	if s.MaxItems != nil && int64(len(v)) > *s.MaxItems {
+		*errs = append(*errs, &SchemaError{Pointer: pointer, Message: fmt.Sprintf("expected at most %d items, got %d", *s.MaxItems, len(v))})
+	}
+	if s.UniqueItems != nil && *s.UniqueItems {
+		for i := range v {
+			for j := i + 1; j < len(v); j++ {
+				if equalValues(v[i], v[j]) {
+					*errs = append(*errs, &SchemaError{Pointer: pointer, Message: fmt.Sprintf("items %d and %d are equal", i, j)})
+				}
+			}
+		}
+	}
+}
+
+func validateString(s *base.Schema, v string, fail func(format string, args ...any)) {
+	n := int64(utf8.RuneCountInString(v))
+	if s.MinLength != nil && n < *s.MinLength {
+		fail("expected at least %d characters, got %d", *s.MinLength, n)
+	}
+	if s.MaxLength != nil && n > *s.MaxLength {
+		fail("expected at most %d characters, got %d", *s.MaxLength, n)
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		switch {
+		case err != nil:
+			fail("invalid pattern '%s': %s", s.Pattern, err)
+		case !re.MatchString(v):
+			fail("value does not match pattern '%s'", s.Pattern)
+		}
+	}
+	if !matchesFormat(s.Format, v) {
+		fail("value is not a valid %s", s.Format)
+	}
+}
+
+func validateNumber(s *base.Schema, v float64, fail func(format string, args ...any)) {
+	if s.Minimum != nil {
+		exclusive := s.ExclusiveMinimum != nil && s.ExclusiveMinimum.IsA() && s.ExclusiveMinimum.A
+		if v < *s.Minimum || (exclusive && v == *s.Minimum) {
+			fail("expected a value %s %v, got %v", map[bool]string{false: ">=", true: ">"}[exclusive], *s.Minimum, v)
+		}
+	}
+	if s.ExclusiveMinimum != nil && s.ExclusiveMinimum.IsB() && v <= s.ExclusiveMinimum.B {
+		fail("expected a value > %v, got %v", s.ExclusiveMinimum.B, v)
+	}
+	if s.Maximum != nil {
+		exclusive := s.ExclusiveMaximum != nil && s.ExclusiveMaximum.IsA() && s.ExclusiveMaximum.A
+		if v > *s.Maximum || (exclusive && v == *s.Maximum) {
+			fail("expected a value %s %v, got %v", map[bool]string{false: "<=", true: "<"}[exclusive], *s.Maximum, v)
+		}
+	}
+	if s.ExclusiveMaximum != nil && s.ExclusiveMaximum.IsB() && v >= s.ExclusiveMaximum.B {
+		fail("expected a value < %v, got %v", s.ExclusiveMaximum.B, v)
+	}
+	if s.MultipleOf != nil && *s.MultipleOf != 0 {
+		if q := v / *s.MultipleOf; q != float64(int64(q)) {
+			fail("expected a multiple of %v, got %v", *s.MultipleOf, v)
+		}
+	}
+}
+
+var uuidPattern = regexp.MustCompile("^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$")
+
+// matchesFormat tells whether v is valid for the format. Unknown formats accept every value.
+func matchesFormat(format string, v string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, v)
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, v)
+		return err == nil
+	case "uuid":
+		return uuidPattern.MatchString(v)
+	case "email":
+		_, err := mail.ParseAddress(v)
+		return err == nil
+	case "ipv4":
+		ip := net.ParseIP(v)
+		return ip != nil && ip.To4() != nil
+	case "ipv6":
+		ip := net.ParseIP(v)
+		return ip != nil && ip.To4() == nil
+	case "uri":
+		u, err := url.Parse(v)
+		return err == nil && u.Scheme != ""
+	}
+	return true
+}
+
+func matchesAnyType(types []string, v any) bool {
+	for _, t := range types {
+		if matchesType(t, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesType(t string, v any) bool {
+	switch t {
+	case "integer":
+		n, ok := toNumber(v)
+		return ok && n == float64(int64(n))
+	case "number":
+		_, ok := toNumber(v)
+		return ok
+	}
+	return jsonType(v) == t
+}
+
+// jsonType returns the JSON type of v, numbers being reported as `number`.
+func jsonType(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	}
+	if _, ok := toNumber(v); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func toNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func matchesEnum(enum []*yaml.Node, v any) bool {
+	for _, n := range enum {
+		if e, err := DecodeNode(n); err == nil && equalValues(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func countMatches(schemas []*base.SchemaProxy, v any) (n int) {
+	for _, sp := range schemas {
+		var errs SchemaErrors
+		validateValue(sp.Schema(), v, "", &errs)
+		if len(errs) == 0 {
+			n++
+		}
+	}
+	return
+}
+
+// equalValues compares two values the way JSON does, numbers being equal regardless of their Go type.
+func equalValues(a any, b any) bool {
+	return reflect.DeepEqual(normalizeNumbers(a), normalizeNumbers(b))
+}
+
+func normalizeNumbers(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[k] = normalizeNumbers(e)
+		}
+		return m
+	case []any:
+		a := make([]any, 0, len(v))
+		for _, e := range v {
+			a = append(a, normalizeNumbers(e))
+		}
+		return a
+	}
+	if n, ok := toNumber(v); ok {
+		return n
+	}
+	return v
+}
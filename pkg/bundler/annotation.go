@@ -0,0 +1,82 @@
+package bundler
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"github.com/pb33f/libopenapi/orderedmap"
+	"github.com/telkomindonesia/openapi-utils/internal/util"
+	"github.com/telkomindonesia/openapi-utils/pkg/reference"
+	"gopkg.in/yaml.v3"
+)
+
+// SourceExtension is the extension holding the Source of a bundled component, see Options.SourceAnnotations.
+const SourceExtension = "x-source"
+
+// Source is the location a bundled component was copied from.
+type Source struct {
+	// File is the slash-separated path of the file, relative to the directory of the root spec.
+	File string `json:"file" yaml:"file"`
+	// Pointer is the JSON pointer of the component inside File.
+	Pointer string `json:"pointer" yaml:"pointer"`
+}
+
+// LookupSource returns the Source of the annotated component enclosing the given JSON pointer inside
+// the bundled spec rooted at root, together with the pointer translated into the source file.
+// It allows tools working on a bundled spec to report their findings against the original files.
+func LookupSource(root *yaml.Node, pointer string) (src Source, translated string, ok bool) {
+	pointer = strings.TrimPrefix(pointer, "#")
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	if len(tokens) < 3 || tokens[0] != "components" {
+		return
+	}
+	component := "/" + strings.Join(tokens[:3], "/")
+	n := util.LookupPointer(root, component+"/"+SourceExtension)
+	if n == nil {
+		return
+	}
+	if err := n.Decode(&src); err != nil || src.File == "" {
+		return Source{}, "", false
+	}
+	return src, src.Pointer + strings.TrimPrefix(pointer, component), true
+}
+
+// annotateSources adds the SourceExtension to every component of the bundled spec rooted at root
+// whose Source is known. Components that are not mappings, such as aliases, are left untouched.
+func annotateSources(root *yaml.Node, sources *orderedmap.Map[string, string]) {
+	for m := range orderedmap.Iterate(context.Background(), sources) {
+		n := util.LookupPointer(root, m.Key())
+		if n == nil || n.Kind != yaml.MappingNode {
+			continue
+		}
+		file, pointer, _ := strings.Cut(m.Value(), "#")
+		addSource(n, Source{File: file, Pointer: "#" + pointer})
+	}
+}
+
+// addSource appends the SourceExtension holding src to the mapping node n.
+func addSource(n *yaml.Node, src Source) {
+	v := &yaml.Node{}
+	if err := v.Encode(src); err != nil {
+		return
+	}
+	n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: SourceExtension}, v)
+}
+
+// traceSources points the errors found inside annotated components of the bundled spec back at their source file.
+func traceSources(errs reference.Errors, root *yaml.Node, specPath string) {
+	for _, e := range errs {
+		src, pointer, ok := LookupSource(root, e.Pointer)
+		if !ok {
+			continue
+		}
+		e.File, e.Pointer = src.File, pointer
+		if specPath != "" {
+			e.File = filepath.Join(filepath.Dir(specPath), filepath.FromSlash(src.File))
+		}
+		// the position is the one inside the bundled spec, which means nothing in the source file
+		e.Line, e.Column = 0, 0
+	}
+	errs.Sort()
+}
@@ -0,0 +1,278 @@
+package bundler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/telkomindonesia/openapi-utils/internal/util"
+	"gopkg.in/yaml.v3"
+)
+
+var errFilterPreserveSource = errors.New("filtering operations is not supported when preserving the source")
+
+// Bundle loads the spec at the given path together with every file it references
+// and renders it as a single document.
+func Bundle(ctx context.Context, specPath string, opts ...Option) (b []byte, err error) {
+	o := Options{BasePath: filepath.Dir(specPath)}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.PreserveSource && o.Filter != nil {
+		return nil, newError(OpBundle, specPath, errFilterPreserveSource)
+	}
+	read := o.readFunc(specPath)
+	files, imported, err := importJSONSchemas(read, filepath.ToSlash(specPath), o.warn)
+	if err != nil {
+		return nil, newError(OpLoad, specPath, fmt.Errorf("fail to import JSON Schema documents: %w", err))
+	}
+	if o.PreserveSource {
+		return preserveSource(readFiles(files, read), filepath.ToSlash(specPath), specPath, o)
+	}
+
+	var doc libopenapi.Document
+	switch {
+	case imported, o.LocalFS != nil:
+		// libopenapi opens the files of LocalFS by their absolute path, they are handed over as they were read
+		doc, err = loadImported(files, specPath, o)
+	default:
+		doc, err = util.LoadDocumentWithConfiguration(specPath, o.documentConfiguration())
+	}
+	if err != nil {
+		return nil, newError(OpLoad, specPath, err)
+	}
+
+	return bundleDocument(ctx, doc, specPath, o)
+}
+
+// BundleDocumentAt bundles the spec at the given path like Bundle and returns the bundled document, whose
+// references are all local.
+func BundleDocumentAt(ctx context.Context, specPath string, opts ...Option) (libopenapi.Document, error) {
+	b, err := Bundle(ctx, specPath, opts...)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := libopenapi.NewDocument(b)
+	if err != nil {
+		return nil, fmt.Errorf("fail to parse bundled spec: %w", err)
+	}
+	return doc, nil
+}
+
+// BundleFS loads the spec at the given root path inside fsys together with every file it references
+// and renders it as a single document. References to other files are resolved from fsys,
+// hence the BasePath and LocalFS options are ignored, and fail the bundling when they point
+// outside of its root directory, such as `../common.yml` from a file at the root.
+func BundleFS(ctx context.Context, fsys fs.FS, root string, opts ...Option) (b []byte, err error) {
+	o := Options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.BasePath, o.LocalFS = "", nil
+	if o.PreserveSource && o.Filter != nil {
+		return nil, newError(OpBundle, root, errFilterPreserveSource)
+	}
+	read := func(name string) ([]byte, error) { return fs.ReadFile(fsys, name) }
+	files, _, err := importJSONSchemas(read, root, o.warn)
+	if err != nil {
+		return nil, newError(OpLoad, root, fmt.Errorf("fail to import JSON Schema documents: %w", err))
+	}
+	if o.PreserveSource {
+		return preserveSource(readFiles(files, read), root, root, o)
+	}
+
+	doc, err := util.LoadDocumentFunc(readFiles(files, read), root, o.documentConfiguration())
+	if err != nil {
+		return nil, newError(OpLoad, root, err)
+	}
+
+	return bundleDocument(ctx, doc, root, o)
+}
+
+// BundleDocument renders an already loaded document as a single document.
+// Only the Strict option is relevant since the document is already loaded.
+func BundleDocument(ctx context.Context, doc libopenapi.Document, opts ...Option) (b []byte, err error) {
+	o := Options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return bundleDocument(ctx, doc, "", o)
+}
+
+func bundleDocument(ctx context.Context, doc libopenapi.Document, specPath string, o Options) (b []byte, err error) {
+	docv3, err := util.BuildV3Model(doc)
+	if err != nil {
+		err = util.RelocateReferenceErrors(err, doc.GetRolodex(), specPath)
+		return nil, newError(OpBundle, specPath, fmt.Errorf("fail to re-build openapi spec: %w", err))
+	}
+	if o.Strict {
+		if err = strictErrors(doc, o); err != nil {
+			err = util.RelocateReferenceErrors(err, doc.GetRolodex(), specPath)
+			return nil, newError(OpBundle, specPath, fmt.Errorf("strict mode: %w", err))
+		}
+	}
+
+	var deletedTags map[string]struct{}
+	if o.Filter != nil {
+		deletedTags = util.DeleteOperations(ctx, &docv3.Model, o.Filter.keep)
+	}
+
+	// create stub components and localize all references
+	components := util.NewStubComponents()
+	err = components.CopyAndLocalizeComponents(docv3, "")
+	if err != nil {
+		err = util.RelocateReferenceErrors(err, doc.GetRolodex(), specPath)
+		return nil, newError(OpBundle, specPath, fmt.Errorf("fail to copy stub components: %w", err))
+	}
+
+	node, err := components.RenderNode(docv3)
+	if err != nil {
+		return nil, newError(OpBundle, specPath, err)
+	}
+	if o.Filter != nil {
+		util.PruneComponents(node, deletedTags)
+	}
+	if o.SourceAnnotations {
+		annotateSources(node, components.Sources)
+	}
+	b, err = yaml.Marshal(node)
+	if err != nil {
+		return nil, newError(OpBundle, specPath, fmt.Errorf("fail to render bundled spec: %w", err))
+	}
+	if o.Strict {
+		if err = checkDangling(b, specPath); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// preserveSource bundles the spec at the given slash-separated root path from its YAML trees, see Options.PreserveSource.
+// specPath is the path errors are reported against.
+func preserveSource(read func(name string) ([]byte, error), root string, specPath string, o Options) (b []byte, err error) {
+	b, err = bundleSource(read, root, o.SourceAnnotations)
+	if err != nil {
+		return nil, newError(OpBundle, specPath, err)
+	}
+	if o.Strict {
+		if err = checkDangling(b, specPath); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// checkDangling fails when the bundled spec b has references that it can not resolve by itself.
+func checkDangling(b []byte, specPath string) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(b, &root); err != nil {
+		return newError(OpBundle, specPath, fmt.Errorf("fail to parse bundled spec: %w", err))
+	}
+	if rerrs := util.DanglingReferences(&root); len(rerrs) > 0 {
+		traceSources(rerrs, &root, specPath)
+		return newError(OpBundle, specPath, fmt.Errorf("strict mode: bundled spec has dangling references: %w", rerrs))
+	}
+	return nil
+}
+
+// readFiles returns a function reading the given files from memory, and the other ones with read.
+func readFiles(files map[string][]byte, read func(name string) ([]byte, error)) func(name string) ([]byte, error) {
+	return func(name string) ([]byte, error) {
+		if b, ok := files[name]; ok {
+			return b, nil
+		}
+		return read(name)
+	}
+}
+
+// ReadFunc returns the function Bundle reads the files of the spec at the given path with, given the same
+// options, so that the files a bundled spec comes from can be read again, including from Options.LocalFS.
+// It takes slash-separated paths.
+func ReadFunc(specPath string, opts ...Option) func(name string) ([]byte, error) {
+	o := Options{BasePath: filepath.Dir(specPath)}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o.readFunc(specPath)
+}
+
+// readFunc returns a function reading the files of the spec at specPath by their slash-separated path.
+// The spec itself is read from the local disk, like libopenapi does, and the files it references
+// from LocalFS when set, where they are located relative to BasePath.
+func (o Options) readFunc(specPath string) func(name string) ([]byte, error) {
+	return func(name string) ([]byte, error) {
+		if o.LocalFS == nil || name == path.Clean(filepath.ToSlash(specPath)) {
+			return os.ReadFile(filepath.FromSlash(name))
+		}
+		rel, err := filepath.Rel(o.BasePath, filepath.FromSlash(name))
+		if err != nil {
+			return nil, err
+		}
+		return fs.ReadFile(o.LocalFS, filepath.ToSlash(rel))
+	}
+}
+
+// loadImported loads the spec at specPath from the given files, keyed by their slash-separated path, which
+// hold every file it references as read by importJSONSchemas, the JSON Schema documents being rewritten.
+func loadImported(files map[string][]byte, specPath string, o Options) (libopenapi.Document, error) {
+	// the files may live outside of the directory of the spec, they are rooted at their common directory
+	abs := make(map[string]string, len(files))
+	var common string
+	for name := range files {
+		a, err := filepath.Abs(filepath.FromSlash(name))
+		if err != nil {
+			return nil, fmt.Errorf("fail to locate '%s': %w", name, err)
+		}
+		abs[name] = a
+		switch dir := filepath.Dir(a); {
+		case common == "":
+			common = dir
+		default:
+			for !strings.HasPrefix(dir+string(filepath.Separator), common+string(filepath.Separator)) && common != filepath.Dir(common) {
+				common = filepath.Dir(common)
+			}
+		}
+	}
+	rooted := make(map[string][]byte, len(files))
+	for name, b := range files {
+		rel, err := filepath.Rel(common, abs[name])
+		if err != nil {
+			return nil, fmt.Errorf("fail to locate '%s': %w", name, err)
+		}
+		rooted[filepath.ToSlash(rel)] = b
+	}
+	root, err := filepath.Abs(specPath)
+	if err != nil {
+		return nil, fmt.Errorf("fail to locate '%s': %w", specPath, err)
+	}
+	root, err = filepath.Rel(common, root)
+	if err != nil {
+		return nil, fmt.Errorf("fail to locate '%s': %w", specPath, err)
+	}
+	// the other files are not read since importJSONSchemas returns every file the spec references
+	read := func(name string) ([]byte, error) { return nil, fs.ErrNotExist }
+	return util.LoadDocumentFunc(readFiles(rooted, read), filepath.ToSlash(root), o.documentConfiguration())
+}
+
+// strictErrors returns the errors libopenapi tolerated while loading doc.
+func strictErrors(doc libopenapi.Document, o Options) error {
+	rolodex := doc.GetRolodex()
+	if rerrs := util.CollectReferenceErrors(rolodex); len(rerrs) > 0 {
+		return rerrs
+	}
+
+	var errs []error
+	if rolodex != nil {
+		errs = append(errs, rolodex.GetCaughtErrors()...)
+	}
+	if o.recorder != nil {
+		errs = append(errs, o.recorder.Errors()...)
+	}
+	return errors.Join(errs...)
+}
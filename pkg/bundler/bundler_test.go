@@ -0,0 +1,417 @@
+package bundler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/pb33f/libopenapi"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"github.com/stretchr/testify/require"
+	"github.com/telkomindonesia/openapi-utils/internal/testutil"
+	"github.com/telkomindonesia/openapi-utils/internal/util"
+	"github.com/telkomindonesia/openapi-utils/pkg/gitfs"
+	"github.com/telkomindonesia/openapi-utils/pkg/reference"
+	"gopkg.in/yaml.v3"
+)
+
+func TestBundle(t *testing.T) {
+	src := "./testdata/profile/profile.yml"
+	bytes, err := Bundle(context.Background(), src)
+	require.NoError(t, err)
+	doc, err := libopenapi.NewDocument(bytes)
+	require.NoError(t, err)
+	_, errs := doc.BuildV3Model()
+	require.NoError(t, errors.Join(errs...))
+}
+
+func TestBundleInlineSchema(t *testing.T) {
+	bytes, err := Bundle(context.Background(), "./testdata/inline/spec.yml")
+	require.NoError(t, err)
+	doc, err := libopenapi.NewDocument(bytes)
+	require.NoError(t, err)
+	docv3, errs := doc.BuildV3Model()
+	require.NoError(t, errors.Join(errs...))
+
+	path, ok := docv3.Model.Paths.PathItems.Get("/profiles")
+	require.True(t, ok)
+	resp, ok := path.Get.Responses.Codes.Get("200")
+	require.True(t, ok)
+	mt, ok := resp.Content.Get("application/json")
+	require.True(t, ok)
+	require.NotNil(t, mt.Schema, "inline schema holding a reference to another file should be kept")
+	require.Equal(t, "#/components/schemas/Profile", mt.Schema.Schema().Items.A.GetReference())
+}
+
+func TestBundleError(t *testing.T) {
+	src := "./testdata/profile/not-exist.yml"
+	_, err := Bundle(context.Background(), src)
+	var e *Error
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, OpLoad, e.Op)
+	require.Equal(t, src, e.Path)
+}
+
+func TestBundleReferenceErrors(t *testing.T) {
+	src := "testdata/broken/spec.yml"
+	_, err := Bundle(context.Background(), src)
+	var errs reference.Errors
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs, 3)
+
+	expected := []reference.Error{
+		{File: "testdata/broken/components/responses.yml", Line: 8, Column: 13, Pointer: "#/components/responses/Ok/content/application~1json/schema", Ref: "#/components/schemas/Gone"},
+		{File: src, Line: 10, Column: 11, Pointer: "#/paths/~1a/get/responses/200", Ref: "components/responses.yml#/components/responses/Missing"},
+		{File: src, Line: 12, Column: 11, Pointer: "#/paths/~1a/get/responses/201", Ref: "#/components/responses/NotHere"},
+	}
+	for i, e := range errs {
+		require.Error(t, e.Err)
+		e.Err = nil
+		require.Equal(t, expected[i], *e)
+	}
+}
+
+func TestBundleStrict(t *testing.T) {
+	src := "testdata/dangling/spec.yml"
+	_, err := Bundle(context.Background(), src)
+	require.NoError(t, err)
+
+	_, err = Bundle(context.Background(), src, WithStrict(true))
+	var errs reference.Errors
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs, 1)
+	require.Equal(t, "schema.yml", errs[0].Ref)
+
+	_, err = Bundle(context.Background(), "testdata/profile/profile.yml", WithStrict(true))
+	require.NoError(t, err)
+
+	_, err = Bundle(context.Background(), src, WithStrict(true), WithSourceAnnotations(true))
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs, 1)
+	require.Equal(t, filepath.Join("testdata", "dangling", "components.yml"), errs[0].File)
+	require.Equal(t, "#/components/responses/Ok/content/application~1json/schema", errs[0].Pointer)
+
+	src = "testdata/dangling/root.yml"
+	_, err = Bundle(context.Background(), src, WithPreserveSource(true))
+	require.NoError(t, err)
+
+	_, err = Bundle(context.Background(), src, WithPreserveSource(true), WithStrict(true))
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs, 1)
+	require.Equal(t, "#/components/schemas/Missing", errs[0].Ref)
+}
+
+func TestBundleSourceAnnotations(t *testing.T) {
+	for _, preserve := range []bool{false, true} {
+		bytes, err := Bundle(context.Background(), "testdata/profile/profile.yml",
+			WithSourceAnnotations(true), WithPreserveSource(preserve))
+		require.NoError(t, err)
+
+		doc, err := libopenapi.NewDocument(bytes)
+		require.NoError(t, err)
+		docv3, errs := doc.BuildV3Model()
+		require.NoError(t, errors.Join(errs...))
+
+		for name, file := range map[string]string{
+			"UUID":          "components/base.yml",
+			"Profile":       "components/profile.yml",
+			"CreateProfile": "components/profile.yml",
+		} {
+			schema, ok := docv3.Model.Components.Schemas.Get(name)
+			require.True(t, ok, "schema %s should exist", name)
+			n, ok := schema.Schema().Extensions.Get(SourceExtension)
+			require.True(t, ok, "schema %s should be annotated", name)
+
+			var src Source
+			require.NoError(t, n.Decode(&src))
+			require.Equal(t, Source{File: file, Pointer: "#/components/schemas/" + name}, src)
+		}
+	}
+}
+
+func TestBundlePreserveSource(t *testing.T) {
+	src := "testdata/preserve/profile.yml"
+	bytes, err := Bundle(context.Background(), src, WithPreserveSource(true))
+	require.NoError(t, err)
+	for _, s := range []string{
+		"# every identifier is a UUID\n",
+		"# profiles are upserted, there is no separate create endpoint\n",
+		"description: server error # not retryable\n",
+		`"400": &badRequest`,
+		`"400": *badRequest`,
+	} {
+		require.Contains(t, string(bytes), s)
+	}
+
+	doc, err := libopenapi.NewDocument(bytes)
+	require.NoError(t, err)
+	docv3, errs := doc.BuildV3Model()
+	require.NoError(t, errors.Join(errs...))
+
+	expected, err := Bundle(context.Background(), src)
+	require.NoError(t, err)
+	edoc, err := libopenapi.NewDocument(expected)
+	require.NoError(t, err)
+	edocv3, errs := edoc.BuildV3Model()
+	require.NoError(t, errors.Join(errs...))
+	var eroot, root yaml.Node
+	require.NoError(t, yaml.Unmarshal(expected, &eroot))
+	require.NoError(t, yaml.Unmarshal(bytes, &root))
+	for _, kind := range []string{"schemas", "parameters", "requestBodies", "responses", "headers"} {
+		require.Equal(t, componentNames(&eroot, kind), componentNames(&root, kind), "%s should be named the same way", kind)
+	}
+	require.Equal(t, edocv3.Model.Paths.PathItems.Len(), docv3.Model.Paths.PathItems.Len())
+}
+
+func componentNames(root *yaml.Node, kind string) (names []string) {
+	n := util.LookupPointer(root, "/components/"+kind)
+	if n == nil {
+		return
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		names = append(names, n.Content[i].Value)
+	}
+	sort.Strings(names)
+	return
+}
+
+func TestBundlePreserveSourceConflict(t *testing.T) {
+	bytes, err := Bundle(context.Background(), "testdata/conflict/spec.yml", WithPreserveSource(true), WithStrict(true))
+	require.NoError(t, err)
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal(bytes, &root))
+	require.Equal(t, []string{"Manager", "Profile", "Profile2"}, componentNames(&root, "schemas"))
+
+	schema := func(path string) *yaml.Node {
+		return util.LookupPointer(&root, "/paths/"+path+"/get/responses/200/content/application~1json/schema")
+	}
+	require.Equal(t, "#/components/schemas/Profile", lookupKey(schema("~1customers~1{id}"), "$ref").Value)
+	require.Equal(t, "#/components/schemas/Profile2", lookupKey(schema("~1employees~1{id}"), "$ref").Value, "a different component should be renamed")
+	require.Equal(t, "#/components/schemas/Profile", lookupKey(schema("~1partners~1{id}"), "$ref").Value, "an identical component should be reused")
+	require.Equal(t, "#/components/schemas/Manager",
+		util.LookupPointer(schema("~1employees~1{id}"), "/properties/manager/$ref").Value, "siblings of a reference should be processed")
+	require.Equal(t, "integer", util.LookupPointer(&root, "/components/schemas/Profile2/properties/id/type").Value)
+}
+
+func TestBundleFilter(t *testing.T) {
+	src := "testdata/filter/spec.yml"
+	tests := []struct {
+		name       string
+		filter     Filter
+		operations []string
+		schemas    []string
+		tags       []string
+	}{
+		{
+			name:       "exclude internal",
+			filter:     Filter{ExcludeInternal: true},
+			operations: []string{"ListProfiles", "CreateProfile"},
+			schemas:    []string{"Profile", "ID"},
+			tags:       []string{"profile", "unused"},
+		},
+		{
+			name:       "audience",
+			filter:     Filter{Audiences: []string{"public"}},
+			operations: []string{"ListProfiles"},
+			schemas:    []string{"Profile", "ID"},
+			tags:       []string{"profile", "unused"},
+		},
+		{
+			name:       "exclude audience",
+			filter:     Filter{ExcludeAudiences: []string{"internal"}},
+			operations: []string{"ListProfiles", "GetProfileAudit", "Health"},
+			schemas:    []string{"Profile", "ID", "Audit", "Actor"},
+			tags:       []string{"profile", "audit", "unused"},
+		},
+		{
+			name:       "tag and path",
+			filter:     Filter{Tags: []string{"audit"}, Paths: []string{"/health"}},
+			operations: []string{"GetProfileAudit", "Health"},
+			schemas:    []string{"Audit", "Actor", "ID"},
+			tags:       []string{"audit", "unused"},
+		},
+		{
+			name:       "path glob",
+			filter:     Filter{Paths: []string{"/profiles/**"}, ExcludeOperationIDs: []string{"Health"}},
+			operations: []string{"GetProfileAudit"},
+			schemas:    []string{"Audit", "Actor", "ID"},
+			tags:       []string{"audit", "unused"},
+		},
+		{
+			name:       "operation id and exclude tag",
+			filter:     Filter{OperationIDs: []string{"CreateProfile", "GetProfileAudit"}, ExcludeTags: []string{"audit"}},
+			operations: []string{"CreateProfile"},
+			schemas:    []string{"Profile", "ID"},
+			tags:       []string{"profile", "unused"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bytes, err := Bundle(context.Background(), src, WithFilter(tt.filter), WithStrict(true))
+			require.NoError(t, err)
+
+			doc, err := libopenapi.NewDocument(bytes)
+			require.NoError(t, err)
+			docv3, errs := doc.BuildV3Model()
+			require.NoError(t, errors.Join(errs...))
+
+			var operations []string
+			for m := range orderedmap.Iterate(context.Background(), docv3.Model.Paths.PathItems) {
+				for _, op := range []*v3.Operation{m.Value().Get, m.Value().Post} {
+					if op != nil {
+						operations = append(operations, op.OperationId)
+					}
+				}
+			}
+			require.ElementsMatch(t, tt.operations, operations)
+
+			var schemas []string
+			for m := range orderedmap.Iterate(context.Background(), docv3.Model.Components.Schemas) {
+				schemas = append(schemas, m.Key())
+			}
+			require.ElementsMatch(t, tt.schemas, schemas)
+
+			var tags []string
+			for _, tag := range docv3.Model.Tags {
+				tags = append(tags, tag.Name)
+			}
+			require.ElementsMatch(t, tt.tags, tags)
+		})
+	}
+
+	_, err := Bundle(context.Background(), src, WithFilter(Filter{ExcludeInternal: true}), WithPreserveSource(true))
+	require.Error(t, err)
+}
+
+func TestBundleFS(t *testing.T) {
+	fsys := testutil.MapFS(t, "./testdata", "specs")
+	bytes, err := BundleFS(context.Background(), fsys, "specs/profile/profile.yml")
+	require.NoError(t, err)
+	expected, err := Bundle(context.Background(), "./testdata/profile/profile.yml")
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(bytes))
+}
+
+func TestBundleLocalFS(t *testing.T) {
+	// the files referenced by the spec only exist inside LocalFS
+	dir := t.TempDir()
+	b, err := os.ReadFile("./testdata/profile/profile.yml")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "profile.yml"), b, 0o644))
+	bytes, err := Bundle(context.Background(), filepath.Join(dir, "profile.yml"),
+		WithBasePath(dir), WithLocalFS(testutil.MapFS(t, "./testdata/profile", "")))
+	require.NoError(t, err)
+	expected, err := Bundle(context.Background(), "./testdata/profile/profile.yml")
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(bytes))
+}
+
+func TestBundleFSOutsideRoot(t *testing.T) {
+	_, err := BundleFS(context.Background(), os.DirFS("testdata/jsonschema/api"), "spec.yml")
+	require.ErrorContains(t, err, "$ref '../schemas/order.schema.json#/$defs/OrderId' in 'spec.yml' points outside of the root directory")
+}
+
+func TestBundleFSGit(t *testing.T) {
+	ctx := context.Background()
+	repo := testutil.GitRepo(t, "./testdata", "specs", "v1")
+	fsys, err := gitfs.New(ctx, repo, "v1")
+	require.NoError(t, err)
+	defer fsys.Close()
+
+	bytes, err := BundleFS(ctx, fsys, "specs/profile/profile.yml")
+	require.NoError(t, err)
+	expected, err := Bundle(ctx, "./testdata/profile/profile.yml")
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(bytes))
+}
+
+func TestBundleJSONSchema(t *testing.T) {
+	bundles := map[string]func() ([]byte, error){
+		"default": func() ([]byte, error) {
+			return Bundle(context.Background(), "./testdata/jsonschema/api/spec.yml", WithStrict(true))
+		},
+		"preserve-source": func() ([]byte, error) {
+			return Bundle(context.Background(), "./testdata/jsonschema/api/spec.yml", WithPreserveSource(true))
+		},
+		"localfs": func() ([]byte, error) {
+			return Bundle(context.Background(), "./testdata/jsonschema/api/spec.yml", WithStrict(true),
+				WithBasePath("testdata/jsonschema"), WithLocalFS(os.DirFS("testdata/jsonschema")))
+		},
+		"fs": func() ([]byte, error) {
+			return BundleFS(context.Background(), os.DirFS("testdata/jsonschema"), "api/spec.yml")
+		},
+	}
+	for name, bundle := range bundles {
+		bytes, err := bundle()
+		require.NoError(t, err, name)
+		doc, err := libopenapi.NewDocument(bytes)
+		require.NoError(t, err, name)
+		docv3, errs := doc.BuildV3Model()
+		require.NoError(t, errors.Join(errs...), name)
+
+		schemas := docv3.Model.Components.Schemas
+		for _, s := range []string{"OrderEnvelope", "Item", "Order", "OrderId", "Item2", "Address", "Location"} {
+			_, ok := schemas.Get(s)
+			require.True(t, ok, "%s: schema %s should exist", name, s)
+		}
+		require.Equal(t, []string{"string"}, schemas.GetOrZero("Item").Schema().Type, "%s: root components win", name)
+
+		order := schemas.GetOrZero("Order").Schema()
+		require.Equal(t, "#/components/schemas/OrderId", order.Properties.GetOrZero("id").GetReference(), name)
+		require.Equal(t, "#/components/schemas/Item2", order.Properties.GetOrZero("items").Schema().Items.A.GetReference(), name)
+		note := order.Properties.GetOrZero("note").Schema()
+		require.Equal(t, []string{"string"}, note.Type, name)
+		require.True(t, *note.Nullable, name)
+		require.Equal(t, "leave at the door", note.Example.Value, name)
+		total := order.Properties.GetOrZero("total").Schema()
+		require.Equal(t, 0.0, *total.Minimum, name)
+		require.True(t, total.ExclusiveMinimum.A, name)
+		discount := order.Properties.GetOrZero("discount").Schema()
+		require.Equal(t, 5.0, *discount.Minimum, "%s: the stricter limit should be kept", name)
+		require.Nil(t, discount.ExclusiveMinimum, name)
+		require.Equal(t, 100.0, *discount.Maximum, name)
+		require.True(t, discount.ExclusiveMaximum.A, name)
+		billing := order.Properties.GetOrZero("billing").Schema()
+		require.Equal(t, "billing address", billing.Description, name)
+		require.Equal(t, "#/components/schemas/Address", billing.AllOf[0].GetReference(), "%s: siblings of $ref should be kept through allOf", name)
+		require.Equal(t, "order", order.Properties.GetOrZero("kind").Schema().Enum[0].Value, name)
+
+		item := schemas.GetOrZero("Item2").Schema()
+		require.Nil(t, item.PropertyNames, "%s: keywords unsupported by OpenAPI 3.0 should be dropped", name)
+		location := schemas.GetOrZero("Location").Schema()
+		require.Len(t, location.Items.A.Schema().AnyOf, 2, name)
+
+		param := docv3.Model.Paths.PathItems.GetOrZero("/orders/{order-id}").Get.Parameters[0]
+		require.Equal(t, "#/components/schemas/OrderId", param.Schema.GetReference(), name)
+	}
+}
+
+func TestBundleJSONSchemaTupleWarning(t *testing.T) {
+	logs := bytes.Buffer{}
+	_, err := Bundle(context.Background(), "./testdata/jsonschema/api/spec.yml", WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	require.NoError(t, err)
+	require.Contains(t, logs.String(), "loosening JSON Schema tuple")
+	require.Contains(t, logs.String(), "file=testdata/jsonschema/schemas/address.json")
+}
+
+func TestBundleJSONSchemaOpenAPI31(t *testing.T) {
+	bytes, err := Bundle(context.Background(), "./testdata/jsonschema/api/spec31.yml")
+	require.NoError(t, err)
+	doc, err := libopenapi.NewDocument(bytes)
+	require.NoError(t, err)
+	docv3, errs := doc.BuildV3Model()
+	require.NoError(t, errors.Join(errs...))
+
+	schemas := docv3.Model.Components.Schemas
+	order := schemas.GetOrZero("Order").Schema()
+	require.Equal(t, []string{"string", "null"}, order.Properties.GetOrZero("note").Schema().Type)
+	require.Equal(t, 0.0, order.Properties.GetOrZero("total").Schema().ExclusiveMinimum.B)
+	require.NotNil(t, schemas.GetOrZero("Item").Schema().PropertyNames)
+	require.Len(t, schemas.GetOrZero("Location").Schema().PrefixItems, 2)
+}
@@ -0,0 +1,7 @@
+// Package bundler bundles an OpenAPI 3 spec split across multiple files into a single document
+// where every referenced component is copied into the root `components` and every reference is local.
+//
+// References to plain JSON Schema documents (files declaring `$schema` instead of `openapi`) are
+// imported as `components/schemas`: their `$defs` become separately named components and keywords
+// of the schema draft are converted to the OpenAPI version of the root spec.
+package bundler
@@ -0,0 +1,31 @@
+package bundler
+
+const (
+	OpLoad   = "load"
+	OpBundle = "bundle"
+)
+
+// Error is the error returned by the bundler. Its Op is either OpLoad or OpBundle, and its Path
+// is the path of the spec being bundled, if any.
+type Error struct {
+	// Op is the operation that failed.
+	Op string
+	// Path is the path of the spec being processed, if any.
+	Path string
+	Err  error
+}
+
+func newError(op string, path string, err error) *Error {
+	return &Error{Op: op, Path: path, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Path == "" {
+		return "fail to " + e.Op + ": " + e.Err.Error()
+	}
+	return "fail to " + e.Op + " " + e.Path + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
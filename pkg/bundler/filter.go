@@ -0,0 +1,123 @@
+package bundler
+
+import (
+	"regexp"
+	"strings"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"gopkg.in/yaml.v3"
+)
+
+// Filter selects the operations kept in the bundled spec. When any of the include criteria is set, only the
+// operations matching at least one of them are kept. Operations matching any of the exclude criteria are then
+// dropped. Components no longer reachable from the kept operations are pruned, so are the top-level tags
+// whose operations were all dropped.
+type Filter struct {
+	// Tags keeps the operations having any of the tags.
+	Tags []string
+	// Paths keeps the operations whose path matches any of the globs, where `*` matches
+	// within a single path segment and `**` matches across segments.
+	Paths []string
+	// OperationIDs keeps the operations having any of the operation ids.
+	OperationIDs []string
+	// Audiences keeps the operations whose `x-audience` extension, a string or a list of strings
+	// set on the operation or its path item, holds any of the audiences.
+	Audiences []string
+
+	ExcludeTags         []string
+	ExcludePaths        []string
+	ExcludeOperationIDs []string
+	ExcludeAudiences    []string
+	// ExcludeInternal drops the operations whose `x-internal` extension, set on the operation or its path item, is true.
+	ExcludeInternal bool
+}
+
+func (f *Filter) include() bool {
+	return len(f.Tags) > 0 || len(f.Paths) > 0 || len(f.OperationIDs) > 0 || len(f.Audiences) > 0
+}
+
+// keep tells whether the operation found under path in the given path item is kept by the filter.
+func (f *Filter) keep(path string, _ string, pathItem *v3.PathItem, op *v3.Operation) bool {
+	audiences := append(extensionStrings(pathItem.Extensions, "x-audience"), extensionStrings(op.Extensions, "x-audience")...)
+	if f.include() &&
+		!containsAny(f.Tags, op.Tags) &&
+		!matchAny(f.Paths, path) &&
+		!containsAny(f.OperationIDs, []string{op.OperationId}) &&
+		!containsAny(f.Audiences, audiences) {
+		return false
+	}
+
+	switch {
+	case containsAny(f.ExcludeTags, op.Tags),
+		matchAny(f.ExcludePaths, path),
+		containsAny(f.ExcludeOperationIDs, []string{op.OperationId}),
+		containsAny(f.ExcludeAudiences, audiences),
+		f.ExcludeInternal && (extensionBool(pathItem.Extensions, "x-internal") || extensionBool(op.Extensions, "x-internal")):
+		return false
+	}
+	return true
+}
+
+func containsAny(values []string, candidates []string) bool {
+	for _, v := range values {
+		for _, c := range candidates {
+			if v == c {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchAny(globs []string, path string) bool {
+	for _, g := range globs {
+		if globRegexp(g).MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+func globRegexp(glob string) *regexp.Regexp {
+	b := strings.Builder{}
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			b.WriteString("[^/]*")
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+func extensionStrings(ext *orderedmap.Map[string, *yaml.Node], key string) (s []string) {
+	if ext == nil {
+		return
+	}
+	n, ok := ext.Get(key)
+	if !ok {
+		return
+	}
+	if n.Kind == yaml.ScalarNode {
+		return []string{n.Value}
+	}
+	_ = n.Decode(&s)
+	return
+}
+
+func extensionBool(ext *orderedmap.Map[string, *yaml.Node], key string) (b bool) {
+	if ext == nil {
+		return
+	}
+	if n, ok := ext.Get(key); ok {
+		_ = n.Decode(&b)
+	}
+	return
+}
@@ -0,0 +1,443 @@
+package bundler
+
+import (
+	"bytes"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/telkomindonesia/openapi-utils/internal/util"
+	"gopkg.in/yaml.v3"
+)
+
+// jsonSchemaImporter rewrites the JSON Schema documents referenced by a spec into files holding schema
+// components, together with the references pointing into them, for the bundlers to copy them as any other
+// component. A JSON Schema document is a file declaring `$schema` without being an OpenAPI document, or a
+// file referenced by one.
+type jsonSchemaImporter struct {
+	read func(name string) ([]byte, error)
+	warn func(msg string, args ...any)
+	// v31 converts the schemas for OpenAPI 3.1 instead of 3.0.
+	v31 bool
+
+	docs    map[string]*yaml.Node
+	raw     map[string][]byte
+	order   []string
+	schemas map[string]*importedSchema
+	used    map[string]struct{}
+}
+
+// importedSchema holds the names of the components created from a JSON Schema document.
+type importedSchema struct {
+	// pointers lists the JSON pointers of the root schema and of its definitions, names their components.
+	pointers []string
+	names    map[string]string
+}
+
+// importJSONSchemas reads the slash-separated root spec and every local file it references, directly or not,
+// with read. It returns the content of every file read, the JSON Schema documents and the files referencing
+// them being rewritten, and whether any JSON Schema document was found.
+func importJSONSchemas(read func(name string) ([]byte, error), root string, warn func(msg string, args ...any)) (files map[string][]byte, imported bool, err error) {
+	im := &jsonSchemaImporter{
+		read:    read,
+		warn:    warn,
+		docs:    map[string]*yaml.Node{},
+		raw:     map[string][]byte{},
+		schemas: map[string]*importedSchema{},
+		used:    map[string]struct{}{},
+	}
+	root = path.Clean(root)
+	im.discover(root, false)
+	if doc := im.docs[root]; doc != nil && len(doc.Content) > 0 {
+		if v := lookupKey(doc.Content[0], "openapi"); v != nil {
+			im.v31 = strings.HasPrefix(v.Value, "3.1")
+		}
+	}
+	if len(im.schemas) == 0 {
+		return im.raw, false, nil
+	}
+
+	for _, file := range im.order {
+		if s, ok := im.schemas[file]; ok {
+			im.name(file, s)
+		}
+	}
+	files = map[string][]byte{}
+	for _, file := range im.order {
+		b, err := im.rewrite(file)
+		if err != nil {
+			return nil, false, err
+		}
+		files[file] = b
+	}
+	return files, true, nil
+}
+
+// discover reads file and the files it references, schema telling whether file is referenced by a JSON Schema document.
+func (im *jsonSchemaImporter) discover(file string, schema bool) {
+	if _, ok := im.docs[file]; ok {
+		return
+	}
+	im.docs[file] = nil
+	b, err := im.read(file)
+	if err != nil {
+		// the bundlers report the references they can not resolve
+		return
+	}
+	doc := &yaml.Node{}
+	if yaml.Unmarshal(b, doc) != nil || len(doc.Content) == 0 {
+		return
+	}
+	im.docs[file], im.raw[file] = doc, b
+	im.order = append(im.order, file)
+
+	body := doc.Content[0]
+	if !schema && body.Kind == yaml.MappingNode && lookupKey(body, "$schema") != nil &&
+		lookupKey(body, "openapi") == nil && lookupKey(body, "swagger") == nil {
+		schema = true
+	}
+	switch {
+	case schema:
+		im.schemas[file] = &importedSchema{names: map[string]string{}}
+	default:
+		if schemas := util.LookupPointer(doc, "/components/schemas"); schemas != nil && schemas.Kind == yaml.MappingNode {
+			for i := 0; i < len(schemas.Content); i += 2 {
+				im.used[schemas.Content[i].Value] = struct{}{}
+			}
+		}
+	}
+
+	util.WalkRefs(doc, func(ref *yaml.Node) {
+		if target, _, ok := util.ResolveRef(file, ref.Value); ok && target != file {
+			im.discover(target, schema)
+		}
+	})
+}
+
+// name names the component of the root schema of a JSON Schema document after its file, and the components of
+// its definitions after their key.
+func (im *jsonSchemaImporter) name(file string, s *importedSchema) {
+	base := path.Base(file)
+	for _, ext := range []string{".json", ".yaml", ".yml", ".schema"} {
+		base = strings.TrimSuffix(base, ext)
+	}
+	s.pointers = append(s.pointers, "")
+	s.names[""] = im.unique(pascal(base))
+
+	var collect func(n *yaml.Node, pointer string)
+	collect = func(n *yaml.Node, pointer string) {
+		for _, keyword := range []string{"$defs", "definitions"} {
+			defs := lookupKey(n, keyword)
+			if defs == nil || defs.Kind != yaml.MappingNode {
+				continue
+			}
+			for i := 0; i+1 < len(defs.Content); i += 2 {
+				p := pointer + "/" + keyword + "/" + util.EscapePointer(defs.Content[i].Value)
+				s.pointers = append(s.pointers, p)
+				s.names[p] = im.unique(componentName(defs.Content[i].Value))
+				collect(defs.Content[i+1], p)
+			}
+		}
+	}
+	collect(im.docs[file].Content[0], "")
+}
+
+func (im *jsonSchemaImporter) unique(name string) string {
+	unique := name
+	for i := 2; ; i++ {
+		if _, ok := im.used[unique]; !ok {
+			break
+		}
+		unique = name + strconv.Itoa(i)
+	}
+	im.used[unique] = struct{}{}
+	return unique
+}
+
+// rewrite returns the content of file, where the references into JSON Schema documents point at their components,
+// and which is turned into a file holding these components when it is itself a JSON Schema document.
+func (im *jsonSchemaImporter) rewrite(file string) ([]byte, error) {
+	doc := im.docs[file]
+	s, ok := im.schemas[file]
+	if !ok {
+		changed := false
+		util.WalkRefs(doc, func(ref *yaml.Node) {
+			if v, ok := im.ref(file, ref.Value); ok {
+				ref.Value, changed = v, true
+			}
+		})
+		if !changed {
+			return im.raw[file], nil
+		}
+		return render(file, doc)
+	}
+
+	schemas := &yaml.Node{Kind: yaml.MappingNode}
+	for _, p := range s.pointers {
+		n := util.LookupPointer(doc, p)
+		schemas.Content = append(schemas.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: s.names[p]}, im.convert(file, n))
+	}
+	out := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: "components"},
+		{Kind: yaml.MappingNode, Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: "schemas"}, schemas}},
+	}}
+	return render(file, &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{out}})
+}
+
+// ref returns the reference to the component a reference written in file points into, if it is a JSON Schema document.
+func (im *jsonSchemaImporter) ref(file string, value string) (string, bool) {
+	target, pointer, ok := util.ResolveRef(file, value)
+	s := im.schemas[target]
+	if !ok || s == nil {
+		return "", false
+	}
+	var match string
+	for _, p := range s.pointers {
+		if (pointer == p || strings.HasPrefix(pointer, p+"/")) && len(p) >= len(match) {
+			match = p
+		}
+	}
+	ref := "#/components/schemas/" + util.EscapePointer(s.names[match]) + strings.TrimPrefix(pointer, match)
+	if target != file {
+		t, _, _ := strings.Cut(value, "#")
+		ref = t + ref
+	}
+	return ref, true
+}
+
+// jsonSchemaOnly lists the keywords OpenAPI 3.0 does not support, which are dropped.
+var jsonSchemaOnly = map[string]bool{
+	"if": true, "then": true, "else": true, "dependentSchemas": true, "dependentRequired": true, "dependencies": true,
+	"unevaluatedProperties": true, "unevaluatedItems": true, "contains": true, "minContains": true, "maxContains": true,
+	"propertyNames": true, "patternProperties": true, "additionalItems": true, "contentEncoding": true,
+	"contentMediaType": true, "contentSchema": true, "$dynamicRef": true, "$dynamicAnchor": true, "$vocabulary": true,
+	"$anchor": true, "$recursiveRef": true, "$recursiveAnchor": true,
+}
+
+var (
+	schemaMaps  = map[string]bool{"properties": true, "patternProperties": true, "dependentSchemas": true}
+	schemaLists = map[string]bool{"allOf": true, "anyOf": true, "oneOf": true, "prefixItems": true}
+	schemaItems = map[string]bool{
+		"additionalProperties": true, "not": true, "contains": true, "propertyNames": true, "if": true, "then": true,
+		"else": true, "unevaluatedProperties": true, "unevaluatedItems": true, "additionalItems": true,
+	}
+)
+
+// convert returns a copy of the JSON Schema n written in file, as a schema of the OpenAPI version of the root spec.
+func (im *jsonSchemaImporter) convert(file string, n *yaml.Node) *yaml.Node {
+	if n == nil {
+		return &yaml.Node{Kind: yaml.MappingNode}
+	}
+	if n.Kind == yaml.AliasNode {
+		return im.convert(file, n.Alias)
+	}
+	if n.Kind != yaml.MappingNode {
+		return copyNode(n, map[*yaml.Node]*yaml.Node{})
+	}
+
+	s := &yaml.Node{Kind: yaml.MappingNode, Style: n.Style &^ yaml.FlowStyle}
+	set := func(key string, v *yaml.Node) {
+		s.Content = append(s.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, v)
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		switch key := k.Value; {
+		case key == "$schema", key == "$id", key == "$comment", key == "$defs", key == "definitions":
+			continue
+		case key == "$ref" && v.Kind == yaml.ScalarNode:
+			ref := v.Value
+			if r, ok := im.ref(file, ref); ok {
+				ref = r
+			}
+			set(key, &yaml.Node{Kind: yaml.ScalarNode, Value: ref})
+		case key == "items" && v.Kind == yaml.SequenceNode:
+			// draft-07 tuples
+			items := im.convertList(file, v)
+			if im.v31 {
+				set("prefixItems", items)
+				continue
+			}
+			im.loosenTuple(file, k)
+			set("items", &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: "anyOf"}, items}})
+		case key == "items":
+			set(key, im.convert(file, v))
+		case key == "dependencies" && im.v31 && v.Kind == yaml.MappingNode:
+			required, schemas := &yaml.Node{Kind: yaml.MappingNode}, &yaml.Node{Kind: yaml.MappingNode}
+			for j := 0; j+1 < len(v.Content); j += 2 {
+				if v.Content[j+1].Kind == yaml.SequenceNode {
+					required.Content = append(required.Content, copyNode(v.Content[j], map[*yaml.Node]*yaml.Node{}), copyNode(v.Content[j+1], map[*yaml.Node]*yaml.Node{}))
+					continue
+				}
+				schemas.Content = append(schemas.Content, copyNode(v.Content[j], map[*yaml.Node]*yaml.Node{}), im.convert(file, v.Content[j+1]))
+			}
+			if len(required.Content) > 0 {
+				set("dependentRequired", required)
+			}
+			if len(schemas.Content) > 0 {
+				set("dependentSchemas", schemas)
+			}
+		case !im.v31 && key == "prefixItems" && v.Kind == yaml.SequenceNode:
+			im.loosenTuple(file, k)
+			set("items", &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: "anyOf"}, im.convertList(file, v)}})
+		case !im.v31 && jsonSchemaOnly[key]:
+			if im.warn != nil {
+				im.warn("dropping JSON Schema keyword unsupported by OpenAPI 3.0", "file", file, "line", k.Line, "keyword", key)
+			}
+		case schemaMaps[key] && v.Kind == yaml.MappingNode:
+			m := &yaml.Node{Kind: yaml.MappingNode}
+			for j := 0; j+1 < len(v.Content); j += 2 {
+				m.Content = append(m.Content, copyNode(v.Content[j], map[*yaml.Node]*yaml.Node{}), im.convert(file, v.Content[j+1]))
+			}
+			set(key, m)
+		case schemaLists[key] && v.Kind == yaml.SequenceNode:
+			set(key, im.convertList(file, v))
+		case schemaItems[key]:
+			set(key, im.convert(file, v))
+		default:
+			set(key, copyNode(v, map[*yaml.Node]*yaml.Node{}))
+		}
+	}
+	if !im.v31 {
+		downgrade(s)
+	}
+	return s
+}
+
+// loosenTuple warns that the tuple held by the keyword k, written in file, accepts any of its schemas at any
+// position once converted, OpenAPI 3.0 having no tuple.
+func (im *jsonSchemaImporter) loosenTuple(file string, k *yaml.Node) {
+	if im.warn != nil {
+		im.warn("loosening JSON Schema tuple unsupported by OpenAPI 3.0 into items matching any of its schemas", "file", file, "line", k.Line, "keyword", k.Value)
+	}
+}
+
+func (im *jsonSchemaImporter) convertList(file string, n *yaml.Node) *yaml.Node {
+	l := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, c := range n.Content {
+		l.Content = append(l.Content, im.convert(file, c))
+	}
+	return l
+}
+
+// downgrade replaces the keywords of s whose meaning changed since OpenAPI 3.0 by their OpenAPI 3.0 counterpart.
+func downgrade(s *yaml.Node) {
+	// a numeric exclusive limit becomes the minimum or maximum, so only the stricter of both is kept
+	skip := map[string]bool{}
+	for exclusive, limit := range map[string]string{"exclusiveMinimum": "minimum", "exclusiveMaximum": "maximum"} {
+		e, l := lookupKey(s, exclusive), lookupKey(s, limit)
+		if e == nil || e.ShortTag() == "!!bool" || l == nil {
+			continue
+		}
+		ev, eerr := strconv.ParseFloat(e.Value, 64)
+		lv, lerr := strconv.ParseFloat(l.Value, 64)
+		switch {
+		case eerr == nil && lerr == nil && limit == "minimum" && lv > ev,
+			eerr == nil && lerr == nil && limit == "maximum" && lv < ev:
+			skip[exclusive] = true
+		default:
+			skip[limit] = true
+		}
+	}
+
+	var nullable bool
+	content := s.Content
+	s.Content = nil
+	for i := 0; i+1 < len(content); i += 2 {
+		k, v := content[i], content[i+1]
+		switch {
+		case skip[k.Value]:
+			continue
+		case k.Value == "type" && v.Kind == yaml.SequenceNode:
+			var types []*yaml.Node
+			for _, t := range v.Content {
+				if t.Value == "null" {
+					nullable = true
+					continue
+				}
+				types = append(types, t)
+			}
+			switch len(types) {
+			case 0:
+			case 1:
+				s.Content = append(s.Content, k, types[0])
+			default:
+				alternatives := &yaml.Node{Kind: yaml.SequenceNode}
+				for _, t := range types {
+					alternatives.Content = append(alternatives.Content, &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: "type"}, t}})
+				}
+				s.Content = append(s.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "anyOf"}, alternatives)
+			}
+		case k.Value == "type" && v.Value == "null":
+			nullable = true
+		case k.Value == "const":
+			s.Content = append(s.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "enum"}, &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{v}})
+		case k.Value == "examples" && v.Kind == yaml.SequenceNode:
+			if len(v.Content) > 0 {
+				s.Content = append(s.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "example"}, v.Content[0])
+			}
+		case (k.Value == "exclusiveMinimum" || k.Value == "exclusiveMaximum") && v.ShortTag() != "!!bool":
+			limit := "minimum"
+			if k.Value == "exclusiveMaximum" {
+				limit = "maximum"
+			}
+			s.Content = append(s.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: limit}, v,
+				k, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"})
+		default:
+			s.Content = append(s.Content, k, v)
+		}
+	}
+	if nullable {
+		s.Content = append(s.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "nullable"}, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"})
+	}
+
+	// OpenAPI 3.0 ignores the siblings of `$ref`, which is then applied through `allOf`
+	for i := 0; i+1 < len(s.Content) && len(s.Content) > 2; i += 2 {
+		if s.Content[i].Value != "$ref" {
+			continue
+		}
+		ref := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{s.Content[i], s.Content[i+1]}}
+		s.Content = append(s.Content[:i], s.Content[i+2:]...)
+		if allOf := lookupKey(s, "allOf"); allOf != nil && allOf.Kind == yaml.SequenceNode {
+			allOf.Content = append([]*yaml.Node{ref}, allOf.Content...)
+			break
+		}
+		s.Content = append(s.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "allOf"}, &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{ref}})
+		break
+	}
+}
+
+// render encodes doc as JSON when file is a JSON file, as YAML otherwise.
+func render(file string, doc *yaml.Node) ([]byte, error) {
+	if path.Ext(file) == ".json" {
+		return util.MarshalJSON(doc)
+	}
+	b := bytes.Buffer{}
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+var validComponentName = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// componentName returns name when it is a valid component name, its PascalCase form otherwise.
+func componentName(name string) string {
+	if validComponentName.MatchString(name) {
+		return name
+	}
+	return pascal(name)
+}
+
+// pascal returns the PascalCase form of s, or `Schema` when s holds no letter nor digit.
+func pascal(s string) string {
+	if p := util.Pascal(s); p != "" {
+		return p
+	}
+	return "Schema"
+}
@@ -0,0 +1,102 @@
+package bundler
+
+import (
+	"io/fs"
+	"log/slog"
+
+	"github.com/pb33f/libopenapi/datamodel"
+	"github.com/telkomindonesia/openapi-utils/internal/util"
+)
+
+// Options holds the settings used to load the spec to be bundled.
+type Options struct {
+	// Logger receives warnings emitted while resolving references.
+	Logger *slog.Logger
+	// BasePath is the directory relative references are resolved from.
+	// It defaults to the directory of the bundled spec.
+	BasePath string
+	// LocalFS is the filesystem relative references are read from, rooted at BasePath.
+	// It defaults to the local disk.
+	LocalFS fs.FS
+	// Strict fails the bundling when any reference can not be resolved or any error is logged
+	// while loading the spec, instead of only logging it. With PreserveSource, the spec is not loaded
+	// by libopenapi and only the references the bundled spec can not resolve by itself fail the bundling.
+	Strict bool
+	// PreserveSource bundles the spec by working on the YAML trees of its files instead of the
+	// libopenapi model, keeping their comments, anchors and key order. Only local references are supported.
+	PreserveSource bool
+	// SourceAnnotations adds an `x-source` extension to every component copied into the bundled spec,
+	// holding the file it was copied from, relative to the directory of the root spec, and its JSON pointer there.
+	SourceAnnotations bool
+	// Filter, when set, keeps only the operations it selects and prunes the components and tags they do not use.
+	// It is not supported together with PreserveSource.
+	Filter *Filter
+
+	recorder *util.LogRecorder
+}
+
+type Option func(*Options)
+
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+func WithBasePath(basePath string) Option {
+	return func(o *Options) {
+		o.BasePath = basePath
+	}
+}
+
+func WithLocalFS(fsys fs.FS) Option {
+	return func(o *Options) {
+		o.LocalFS = fsys
+	}
+}
+
+// WithStrict enables the strict mode, see Options.Strict.
+func WithStrict(strict bool) Option {
+	return func(o *Options) {
+		o.Strict = strict
+	}
+}
+
+// WithPreserveSource enables bundling from the source YAML trees, see Options.PreserveSource.
+func WithPreserveSource(preserve bool) Option {
+	return func(o *Options) {
+		o.PreserveSource = preserve
+	}
+}
+
+// WithSourceAnnotations enables the `x-source` extension on bundled components, see Options.SourceAnnotations.
+func WithSourceAnnotations(annotate bool) Option {
+	return func(o *Options) {
+		o.SourceAnnotations = annotate
+	}
+}
+
+// WithFilter keeps only the operations selected by f in the bundled spec, see Filter.
+func WithFilter(f Filter) Option {
+	return func(o *Options) {
+		o.Filter = &f
+	}
+}
+
+// warn logs a warning with the Logger, or the default logger when it is not set.
+func (o *Options) warn(msg string, args ...any) {
+	logger := o.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.Warn(msg, args...)
+}
+
+func (o *Options) documentConfiguration() *datamodel.DocumentConfiguration {
+	config := util.LoadOptions{Logger: o.Logger, BasePath: o.BasePath, LocalFS: o.LocalFS}.DocumentConfiguration()
+	if o.Strict {
+		o.recorder = util.NewLogRecorder(config.Logger, slog.LevelError)
+		config.Logger = o.recorder.Logger()
+	}
+	return config
+}
@@ -0,0 +1,346 @@
+package bundler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/telkomindonesia/openapi-utils/internal/util"
+	"github.com/telkomindonesia/openapi-utils/pkg/reference"
+	"gopkg.in/yaml.v3"
+)
+
+var componentPointer = regexp.MustCompile(`^/components/([^/]+)/([^/]+)$`)
+
+// sourceBundler bundles a spec by working directly on the `yaml.Node` trees of its files,
+// so comments, anchors and key order of the source files are kept in the output.
+type sourceBundler struct {
+	read     func(name string) ([]byte, error)
+	root     string
+	annotate bool
+
+	docs       map[string]*yaml.Node
+	components *yaml.Node
+	copied     map[string]string
+	// owners holds the location of the component copied under every name, keyed by kind and name.
+	owners   map[string]string
+	inlining map[string]struct{}
+	errs     reference.Errors
+}
+
+// bundleSource bundles the spec at the slash-separated root path, reading every file with read.
+// When annotate is set, copied components carry their Source.
+func bundleSource(read func(name string) ([]byte, error), root string, annotate bool) ([]byte, error) {
+	sb := &sourceBundler{
+		read:     read,
+		root:     path.Clean(root),
+		annotate: annotate,
+		docs:     map[string]*yaml.Node{},
+		copied:   map[string]string{},
+		owners:   map[string]string{},
+		inlining: map[string]struct{}{},
+	}
+
+	doc, err := sb.load(sb.root)
+	if err != nil {
+		return nil, fmt.Errorf("fail to load spec: %w", err)
+	}
+	out := copyNode(doc, map[*yaml.Node]*yaml.Node{})
+	if len(out.Content) == 0 || out.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("spec '%s' is not a mapping", sb.root)
+	}
+	hasComponents := lookupKey(out.Content[0], "components") != nil
+	sb.components = mappingEntry(out.Content[0], "components")
+
+	sb.process(out, sb.root, "#")
+	if len(sb.errs) > 0 {
+		sb.errs.Sort()
+		return nil, sb.errs
+	}
+	if !hasComponents && len(sb.components.Content) == 0 {
+		out.Content[0].Content = out.Content[0].Content[:len(out.Content[0].Content)-2]
+	}
+
+	b := bytes.Buffer{}
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err = enc.Encode(out); err != nil {
+		return nil, fmt.Errorf("fail to render spec: %w", err)
+	}
+	if err = enc.Close(); err != nil {
+		return nil, fmt.Errorf("fail to render spec: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+func (sb *sourceBundler) load(name string) (*yaml.Node, error) {
+	if doc, ok := sb.docs[name]; ok {
+		return doc, nil
+	}
+	b, err := sb.read(name)
+	if err != nil {
+		return nil, err
+	}
+	doc := &yaml.Node{}
+	if err = yaml.Unmarshal(b, doc); err != nil {
+		return nil, fmt.Errorf("fail to parse '%s': %w", name, err)
+	}
+	sb.docs[name] = doc
+	return doc, nil
+}
+
+// process replaces every `$ref` found under n, which comes from file, by a reference to a component
+// copied into the output or, for anything else than a component, by the referenced content itself.
+func (sb *sourceBundler) process(n *yaml.Node, file string, pointer string) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			sb.process(c, file, pointer)
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			sb.process(c, file, pointer+"/"+strconv.Itoa(i))
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			if k.Value == "$ref" && v.Kind == yaml.ScalarNode {
+				inlined, err := sb.resolve(n, v, file)
+				if err != nil {
+					sb.errs = append(sb.errs, &reference.Error{
+						File: file, Line: n.Line, Column: n.Column, Pointer: pointer, Ref: v.Value, Err: err,
+					})
+				}
+				if inlined {
+					// n has been replaced by the already processed referenced content
+					return
+				}
+				continue
+			}
+			sb.process(v, file, pointer+"/"+util.EscapePointer(k.Value))
+		}
+	}
+}
+
+// resolve rewrites the reference ref held by n, which comes from file, telling whether n has been
+// replaced by the referenced content.
+func (sb *sourceBundler) resolve(n *yaml.Node, ref *yaml.Node, file string) (inlined bool, err error) {
+	target, pointer, _ := strings.Cut(ref.Value, "#")
+	switch {
+	case strings.HasPrefix(target, "http://"), strings.HasPrefix(target, "https://"):
+		return false, errors.New("remote references are not supported when preserving the source")
+	case target == "":
+		target = file
+	default:
+		target = path.Join(path.Dir(file), target)
+	}
+
+	m := componentPointer.FindStringSubmatch(pointer)
+	switch {
+	case m != nil && target == sb.root:
+		ref.Value = "#" + pointer
+		return false, nil
+	case m != nil:
+		local, err := sb.copyComponent(target, pointer, m[1], m[2])
+		if err != nil {
+			return false, err
+		}
+		ref.Value = local
+		return false, nil
+	default:
+		if err = sb.inline(n, target, pointer); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+}
+
+func (sb *sourceBundler) locate(file string, pointer string) (*yaml.Node, error) {
+	doc, err := sb.load(file)
+	if err != nil {
+		return nil, err
+	}
+	node := util.LookupPointer(doc, pointer)
+	if node == nil {
+		return nil, fmt.Errorf("'%s' does not exist in '%s'", "#"+pointer, file)
+	}
+	return node, nil
+}
+
+func (sb *sourceBundler) copyComponent(file string, pointer string, kind string, name string) (string, error) {
+	key := file + "#" + pointer
+	if local, ok := sb.copied[key]; ok {
+		return local, nil
+	}
+	src, err := sb.locate(file, pointer)
+	if err != nil {
+		return "", err
+	}
+
+	// components keep their name as in the default mode: the root ones win, then the first one copied,
+	// while a different component of the same name is copied under the name suffixed by a number
+	comps := mappingEntry(sb.components, kind)
+	unique := name
+	for i := 2; ; i++ {
+		owner, ok := sb.owners[kind+"/"+unique]
+		if !ok && lookupKey(comps, unique) != nil {
+			owner, ok = sb.root+"#/components/"+kind+"/"+unique, true
+		}
+		if !ok {
+			break
+		}
+		if sb.sameComponent(owner, src) {
+			sb.copied[key] = "#/components/" + kind + "/" + unique
+			return sb.copied[key], nil
+		}
+		unique = name + strconv.Itoa(i)
+	}
+	local := "#/components/" + kind + "/" + unique
+	sb.copied[key] = local
+	sb.owners[kind+"/"+unique] = key
+
+	// comments written above a component belong to its key
+	k := &yaml.Node{Kind: yaml.ScalarNode, Value: unique}
+	if doc, err := sb.load(file); err == nil {
+		if sk := lookupKeyNode(util.LookupPointer(doc, "/components/"+kind), name); sk != nil {
+			k.HeadComment, k.LineComment, k.FootComment = sk.HeadComment, sk.LineComment, sk.FootComment
+		}
+	}
+	node := copyNode(src, map[*yaml.Node]*yaml.Node{})
+	comps.Content = append(comps.Content, k, node)
+	sb.process(node, file, "#"+pointer)
+	if sb.annotate && node.Kind == yaml.MappingNode {
+		rel, err := filepath.Rel(filepath.FromSlash(path.Dir(sb.root)), filepath.FromSlash(file))
+		if err != nil {
+			rel = file
+		}
+		addSource(node, Source{File: filepath.ToSlash(rel), Pointer: "#" + pointer})
+	}
+	return local, nil
+}
+
+// sameComponent tells whether the component at the given location, made of the file and the JSON pointer
+// inside it, has the same content as the node src.
+func (sb *sourceBundler) sameComponent(location string, src *yaml.Node) bool {
+	file, pointer, _ := strings.Cut(location, "#")
+	n, err := sb.locate(file, pointer)
+	return err == nil && sameNode(n, src)
+}
+
+func (sb *sourceBundler) inline(n *yaml.Node, file string, pointer string) error {
+	key := file + "#" + pointer
+	if _, ok := sb.inlining[key]; ok {
+		return errors.New("circular reference can only be preserved when it points to a component")
+	}
+	src, err := sb.locate(file, pointer)
+	if err != nil {
+		return err
+	}
+
+	sb.inlining[key] = struct{}{}
+	defer delete(sb.inlining, key)
+
+	node := copyNode(src, map[*yaml.Node]*yaml.Node{})
+	sb.process(node, file, "#"+pointer)
+	if pointer == "" {
+		doc, _ := sb.load(file)
+		node.HeadComment = joinComments(doc.HeadComment, node.HeadComment)
+		node.FootComment = joinComments(node.FootComment, doc.FootComment)
+	}
+	node.HeadComment = joinComments(n.HeadComment, node.HeadComment)
+	node.LineComment = joinComments(n.LineComment, node.LineComment)
+	node.FootComment = joinComments(node.FootComment, n.FootComment)
+	*n = *node
+	return nil
+}
+
+func lookupKey(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func lookupKeyNode(m *yaml.Node, key string) *yaml.Node {
+	if m == nil {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i]
+		}
+	}
+	return nil
+}
+
+// mappingEntry returns the mapping stored under key inside the mapping m, appending it when missing.
+func mappingEntry(m *yaml.Node, key string) *yaml.Node {
+	if v := lookupKey(m, key); v != nil {
+		return v
+	}
+	v := &yaml.Node{Kind: yaml.MappingNode}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, v)
+	return v
+}
+
+// copyNode deep copies n. Aliases are kept when their anchor is copied too, otherwise they are expanded.
+func copyNode(n *yaml.Node, copied map[*yaml.Node]*yaml.Node) *yaml.Node {
+	if n.Kind == yaml.AliasNode {
+		if anchor, ok := copied[n.Alias]; ok {
+			c := *n
+			c.Alias = anchor
+			return &c
+		}
+		c := copyNode(n.Alias, copied)
+		c.Anchor = ""
+		return c
+	}
+
+	c := *n
+	copied[n] = &c
+	c.Content = make([]*yaml.Node, 0, len(n.Content))
+	for _, child := range n.Content {
+		c.Content = append(c.Content, copyNode(child, copied))
+	}
+	return &c
+}
+
+// sameNode tells whether a and b hold the same content, regardless of their comments, styles and positions.
+func sameNode(a, b *yaml.Node) bool {
+	for a.Kind == yaml.AliasNode {
+		a = a.Alias
+	}
+	for b.Kind == yaml.AliasNode {
+		b = b.Alias
+	}
+	if a.Kind != b.Kind || a.ShortTag() != b.ShortTag() || a.Value != b.Value || len(a.Content) != len(b.Content) {
+		return false
+	}
+	for i := range a.Content {
+		if !sameNode(a.Content[i], b.Content[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func joinComments(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	default:
+		return a + "\n" + b
+	}
+}
@@ -0,0 +1,7 @@
+// Package codegen generates code from an OpenAPI 3 spec.
+//
+// GenerateGo generates a single Go file holding a type for every schema component, a typed client, a server
+// interface with its http.Handler and, for the operations of a compiled proxy spec, a server forwarding them
+// to their upstream through typed upstream clients. The components prefixed by the proxy compilation are
+// recognized, and the generated identifiers never conflict with each other.
+package codegen
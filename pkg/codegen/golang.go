@@ -0,0 +1,1033 @@
+package codegen
+
+import (
+	"context"
+	"fmt"
+	"go/format"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"github.com/telkomindonesia/openapi-utils/internal/util"
+	"github.com/telkomindonesia/openapi-utils/pkg/bundler"
+	"github.com/telkomindonesia/openapi-utils/pkg/proxy"
+)
+
+// GenerateGo generates the Go code of the spec at the given path, bundled by bundler.BundleDocumentAt,
+// see GenerateGoDocument.
+func GenerateGo(ctx context.Context, specPath string, opts ...Option) ([]byte, error) {
+	doc, err := bundler.BundleDocumentAt(ctx, specPath, bundler.WithLogger(newOptions(opts).Logger))
+	if err != nil {
+		return nil, err
+	}
+	return GenerateGoDocument(doc, opts...)
+}
+
+// GenerateGoDocument generates the Go code of doc, whose references are expected to be local, such as the
+// ones of a bundled spec or of a compiled proxy spec. The operations of a compiled proxy spec are forwarded
+// to their upstream by the generated Proxy.
+func GenerateGoDocument(doc libopenapi.Document, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	docv3, err := util.BuildV3Model(doc)
+	if err != nil {
+		return nil, fmt.Errorf("fail to build v3 model: %w", err)
+	}
+
+	g := &goGenerator{
+		names:   map[string]struct{}{},
+		schemas: map[string]string{},
+	}
+	for _, name := range runtimeIdentifiers {
+		g.names[name] = struct{}{}
+	}
+	for _, name := range []string{"ServerInterface", "NewHandler", "Client", "Proxy", "NewProxy", "Injector", "UnimplementedInjector"} {
+		g.names[name] = struct{}{}
+	}
+	if err = g.collect(&docv3.Model); err != nil {
+		return nil, err
+	}
+
+	g.p("// Code generated by openapi-utils. DO NOT EDIT.")
+	g.p("")
+	g.p("package %s", o.Package)
+	g.p("")
+	g.p("import (")
+	for _, i := range goImports {
+		g.p("%q", i)
+	}
+	g.p(")")
+	g.writeSchemas(docv3.Model.Components)
+	for _, op := range g.ops {
+		g.writeOperationTypes(op)
+	}
+	g.writeServer()
+	g.writeClient()
+	g.writeProxy()
+	g.b.WriteString(goRuntime)
+
+	b, err := format.Source([]byte(g.b.String()))
+	if err != nil {
+		return nil, fmt.Errorf("fail to format generated code: %w", err)
+	}
+	return b, nil
+}
+
+func newOptions(opts []Option) (o Options) {
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.Package == "" {
+		o.Package = "api"
+	}
+	return
+}
+
+type goGenerator struct {
+	b strings.Builder
+	// names holds every package-level identifier declared so far.
+	names map[string]struct{}
+	// schemas maps the names of the schema components to the names of their types.
+	schemas map[string]string
+	// prefixes holds the names of the proxies, which prefix the components copied from their upstream.
+	prefixes []string
+	ops      []*goOperation
+	upstream []*goUpstream
+}
+
+type goOperation struct {
+	Name      string
+	Method    string
+	Path      string
+	Summary   string
+	Params    []goParam
+	Body      *goBody
+	Responses []goResponse
+	Proxy     *goProxied
+
+	ParamsType   string
+	ResponseType string
+	InjectType   string
+}
+
+type goParam struct {
+	Name     string
+	In       string
+	Field    string
+	Type     string
+	Required bool
+	// Value is the value of an injected parameter set by the proxy spec, if any.
+	Value string
+}
+
+type goBody struct {
+	Type        string
+	ContentType string
+	Required    bool
+}
+
+type goResponse struct {
+	Key   string
+	Field string
+	Type  string
+}
+
+type goProxied struct {
+	Upstream *goUpstream
+	Path     string
+	Method   string
+	Inject   []goParam
+}
+
+type goUpstream struct {
+	Name     string
+	Type     string
+	Field    string
+	Server   string
+	Security []proxy.SecurityMapping
+}
+
+// xProxy is the `x-proxy` extension left on the operations of a compiled proxy spec.
+type xProxy struct {
+	Name   string       `yaml:"name"`
+	Spec   string       `yaml:"spec"`
+	Path   string       `yaml:"path"`
+	Method string       `yaml:"method"`
+	Inject proxy.Inject `yaml:"inject"`
+}
+
+// collect declares the names of the schema types then gathers the operations, their proxies first
+// for the prefixes to be known.
+func (g *goGenerator) collect(doc *v3.Document) error {
+	type entry struct {
+		method string
+		path   string
+		op     *v3.Operation
+		item   *v3.PathItem
+		x      *xProxy
+	}
+	var entries []entry
+	upstreams := map[string]*goUpstream{}
+	if doc.Paths != nil {
+		for m := doc.Paths.PathItems.First(); m != nil; m = m.Next() {
+			for _, method := range util.Methods {
+				op := util.GetOperation(m.Value(), method)
+				if op == nil {
+					continue
+				}
+				e := entry{method: method, path: m.Key(), op: op, item: m.Value()}
+				if n, ok := op.Extensions.Get("x-proxy"); ok && n != nil {
+					e.x = &xProxy{}
+					if err := n.Decode(e.x); err != nil {
+						return fmt.Errorf("fail to decode `x-proxy` of %s %s: %w", method, m.Key(), err)
+					}
+					name := proxy.Proxy{Name: e.x.Name, Spec: e.x.Spec}.GetName()
+					if _, ok := upstreams[name]; !ok {
+						u := &goUpstream{Name: name}
+						if n, ok := op.Extensions.Get("x-proxy-server"); ok && n != nil {
+							u.Server = n.Value
+						}
+						if n, ok := op.Extensions.Get("x-proxy-security"); ok && n != nil {
+							_ = n.Decode(&u.Security)
+						}
+						upstreams[name] = u
+						g.upstream = append(g.upstream, u)
+						g.prefixes = append(g.prefixes, name)
+					}
+				}
+				entries = append(entries, e)
+			}
+		}
+	}
+	sort.Slice(g.prefixes, func(i, j int) bool { return len(g.prefixes[i]) > len(g.prefixes[j]) })
+
+	if err := g.nameSchemas(doc.Components); err != nil {
+		return err
+	}
+	for _, u := range g.upstream {
+		u.Field = goName(u.Name)
+		u.Type = g.declare(u.Field + "Upstream")
+	}
+
+	for _, e := range entries {
+		op, err := g.operation(e.method, e.path, e.item, e.op)
+		if err != nil {
+			return err
+		}
+		if e.x != nil {
+			u := upstreams[proxy.Proxy{Name: e.x.Name, Spec: e.x.Spec}.GetName()]
+			op.Proxy = &goProxied{Upstream: u, Path: e.x.Path, Method: strings.ToUpper(e.x.Method)}
+			for _, p := range e.x.Inject.Parameters {
+				op.Proxy.Inject = append(op.Proxy.Inject, goParam{Name: p.Name, In: p.In, Field: goName(p.Name), Type: "string", Value: p.Value})
+			}
+			if len(op.Proxy.Inject) > 0 {
+				op.InjectType = g.declare(op.Name + "Inject")
+			}
+			if err = checkUpstreamPath(op); err != nil {
+				return err
+			}
+		}
+		g.ops = append(g.ops, op)
+	}
+	return nil
+}
+
+// checkUpstreamPath ensures every parameter of the upstream path is either a parameter of op or injected.
+func checkUpstreamPath(op *goOperation) error {
+	for _, m := range templateParam.FindAllStringSubmatch(op.Proxy.Path, -1) {
+		if _, ok := op.pathSource(m[1]); !ok {
+			return fmt.Errorf("fail to forward %s %s: upstream path parameter '%s' is neither a parameter nor injected", op.Method, op.Path, m[1])
+		}
+	}
+	return nil
+}
+
+func (g *goGenerator) operation(method string, path string, item *v3.PathItem, op *v3.Operation) (*goOperation, error) {
+	name := goName(op.OperationId)
+	if name == "" {
+		name = goName(method + " " + path)
+	}
+	o := &goOperation{Name: g.declare(name), Method: strings.ToUpper(method), Path: path, Summary: op.Summary}
+
+	for _, p := range util.CopyParameters(op.Parameters, item.Parameters...) {
+		o.Params = append(o.Params, goParam{
+			Name:     p.Name,
+			In:       p.In,
+			Field:    goName(p.Name),
+			Type:     g.paramType(p),
+			Required: p.In == "path" || (p.Required != nil && *p.Required),
+		})
+	}
+	if len(o.Params) > 0 {
+		o.ParamsType = g.declare(o.Name + "Params")
+	}
+
+	if rb := op.RequestBody; rb != nil && orderedmap.Len(rb.Content) > 0 {
+		o.Body = &goBody{Type: "[]byte", Required: rb.Required != nil && *rb.Required}
+		contentType, mt := jsonMediaType(rb.Content)
+		if mt != nil {
+			o.Body.Type, o.Body.ContentType = g.goType(mt.Schema), contentType
+		} else {
+			o.Body.ContentType = rb.Content.First().Key()
+		}
+		if !o.Body.Required && !nilable(o.Body.Type) {
+			o.Body.Type = "*" + o.Body.Type
+		}
+	}
+
+	o.ResponseType = g.declare(o.Name + "Response")
+	if op.Responses != nil {
+		for m := op.Responses.Codes.First(); m != nil; m = m.Next() {
+			o.Responses = append(o.Responses, g.response(m.Key(), m.Value()))
+		}
+		if op.Responses.Default != nil {
+			o.Responses = append(o.Responses, g.response("default", op.Responses.Default))
+		}
+	}
+	// exact statuses take precedence over ranges, which take precedence over the default response
+	sort.SliceStable(o.Responses, func(i, j int) bool { return responseRank(o.Responses[i].Key) < responseRank(o.Responses[j].Key) })
+	return o, nil
+}
+
+func (g *goGenerator) response(key string, r *v3.Response) goResponse {
+	resp := goResponse{Key: key, Field: "JSON" + strings.ToUpper(key)}
+	if key == "default" {
+		resp.Field = "JSONDefault"
+	}
+	if _, mt := jsonMediaType(r.Content); mt != nil {
+		resp.Type = g.goType(mt.Schema)
+	}
+	return resp
+}
+
+func responseRank(key string) int {
+	switch {
+	case key == "default":
+		return 2
+	case strings.HasSuffix(strings.ToUpper(key), "XX"):
+		return 1
+	}
+	return 0
+}
+
+// responseCondition returns the Go condition matching the statuses of a response.
+func responseCondition(key string) string {
+	switch {
+	case key == "default":
+		return "true"
+	case strings.HasSuffix(strings.ToUpper(key), "XX"):
+		return "status/100 == " + key[:1]
+	}
+	return "status == " + key
+}
+
+func jsonMediaType(content *orderedmap.Map[string, *v3.MediaType]) (string, *v3.MediaType) {
+	for m := content.First(); m != nil; m = m.Next() {
+		if t := m.Key(); (t == "application/json" || strings.HasSuffix(t, "+json")) && m.Value().Schema != nil {
+			return t, m.Value()
+		}
+	}
+	return "", nil
+}
+
+// paramType returns the type of a parameter, strings being used for the schemas that are neither
+// scalars nor arrays of scalars.
+func (g *goGenerator) paramType(p *v3.Parameter) string {
+	if p.Schema == nil {
+		return "string"
+	}
+	s := p.Schema.Schema()
+	if s == nil {
+		return "string"
+	}
+	t := g.goType(p.Schema)
+	switch util.SchemaType(s) {
+	case "string", "integer", "number", "boolean":
+		if len(s.AllOf)+len(s.OneOf)+len(s.AnyOf) == 0 {
+			return t
+		}
+	case "array":
+		if s.Items != nil && s.Items.IsA() && s.Items.A != nil {
+			if items := s.Items.A.Schema(); items != nil {
+				switch util.SchemaType(items) {
+				case "string", "integer", "number", "boolean":
+					return t
+				}
+			}
+		}
+	}
+	return "string"
+}
+
+// goType returns the Go type of the schema, the name of the type of its component when it is a reference.
+func (g *goGenerator) goType(sp *base.SchemaProxy) string {
+	if sp == nil {
+		return "any"
+	}
+	if sp.IsReference() {
+		if name, ok := g.schemas[strings.TrimPrefix(sp.GetReference(), "#/components/schemas/")]; ok {
+			return name
+		}
+	}
+	return g.inlineType(sp.Schema())
+}
+
+func (g *goGenerator) inlineType(s *base.Schema) string {
+	if s == nil {
+		return "any"
+	}
+	switch {
+	case len(s.AllOf) == 1 && orderedmap.Len(s.Properties) == 0:
+		return g.goType(s.AllOf[0])
+	case len(s.AllOf) > 0:
+		return g.structType(s)
+	case len(s.OneOf) > 0, len(s.AnyOf) > 0:
+		return "json.RawMessage"
+	}
+
+	switch util.SchemaType(s) {
+	case "string":
+		if s.Format == "date-time" {
+			return "time.Time"
+		}
+		return "string"
+	case "integer":
+		if s.Format == "int32" {
+			return "int32"
+		}
+		return "int64"
+	case "number":
+		if s.Format == "float" {
+			return "float32"
+		}
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		if s.Items != nil && s.Items.IsA() {
+			return "[]" + g.goType(s.Items.A)
+		}
+		return "[]any"
+	case "object":
+		if orderedmap.Len(s.Properties) > 0 {
+			return g.structType(s)
+		}
+		if s.AdditionalProperties != nil && s.AdditionalProperties.IsA() {
+			return "map[string]" + g.goType(s.AdditionalProperties.A)
+		}
+		return "map[string]any"
+	}
+	return "any"
+}
+
+type goField struct {
+	name     string
+	schema   *base.SchemaProxy
+	required bool
+}
+
+// fields returns the properties of s merged with the ones of its `allOf` schemas.
+func fields(s *base.Schema, depth int) (f []goField) {
+	if s == nil || depth > 8 {
+		return nil
+	}
+	for _, sp := range s.AllOf {
+		f = append(f, fields(sp.Schema(), depth+1)...)
+	}
+	for m := s.Properties.First(); m != nil; m = m.Next() {
+		f = append(f, goField{name: m.Key(), schema: m.Value()})
+	}
+	for i := range f {
+		for _, r := range s.Required {
+			if f[i].name == r {
+				f[i].required = true
+			}
+		}
+	}
+	return
+}
+
+func (g *goGenerator) structType(s *base.Schema) string {
+	b := strings.Builder{}
+	b.WriteString("struct {\n")
+	seen := map[string]int{}
+	for _, f := range fields(s, 0) {
+		if i, ok := seen[f.name]; ok && i >= 0 {
+			continue
+		}
+		seen[f.name] = 0
+		t := g.goType(f.schema)
+		tag := f.name
+		if !f.required {
+			tag += ",omitempty"
+			if !nilable(t) && !skipOptionalPointer(f.schema) {
+				t = "*" + t
+			}
+		}
+		if d := f.schema.Schema(); d != nil && d.Description != "" {
+			b.WriteString(comment(d.Description))
+		}
+		fmt.Fprintf(&b, "%s %s `json:%q`\n", uniqueField(seen, goName(f.name)), t, tag)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// uniqueField returns name, or name suffixed with a number when another field of the struct already has it.
+func uniqueField(seen map[string]int, name string) string {
+	key := "\x00" + name
+	n := seen[key]
+	seen[key] = n + 1
+	if n == 0 {
+		return name
+	}
+	return name + strconv.Itoa(n+1)
+}
+
+func skipOptionalPointer(sp *base.SchemaProxy) bool {
+	s := sp.Schema()
+	if s == nil {
+		return false
+	}
+	n, ok := s.Extensions.Get("x-go-type-skip-optional-pointer")
+	return ok && n != nil && n.Value == "true"
+}
+
+func nilable(t string) bool {
+	for _, p := range []string{"[]", "map[", "*"} {
+		if strings.HasPrefix(t, p) {
+			return true
+		}
+	}
+	return t == "any" || t == "json.RawMessage"
+}
+
+// declare returns name, or name suffixed with a number when another package-level identifier already has it.
+func (g *goGenerator) declare(name string) string {
+	unique := name
+	for i := 2; ; i++ {
+		if _, ok := g.names[unique]; !ok {
+			break
+		}
+		unique = name + strconv.Itoa(i)
+	}
+	g.names[unique] = struct{}{}
+	return unique
+}
+
+// upstreamOf returns the proxy whose prefix the component name starts with, together with the unprefixed name.
+func (g *goGenerator) upstreamOf(name string) (prefix string, local string, ok bool) {
+	for _, p := range g.prefixes {
+		if rest, found := strings.CutPrefix(name, p); found && rest != "" {
+			return p, rest, true
+		}
+	}
+	return "", name, false
+}
+
+// nameSchemas declares the names of the types of the schema components. The components copied from an upstream
+// are named without the prefix of their proxy, unless another schema or a generated identifier has the same name,
+// in which case they keep it. The names still conflicting with a generated identifier are suffixed with `Schema`.
+func (g *goGenerator) nameSchemas(c *v3.Components) error {
+	if c == nil {
+		return nil
+	}
+	wanted := map[string]int{}
+	for m := c.Schemas.First(); m != nil; m = m.Next() {
+		_, local, _ := g.upstreamOf(m.Key())
+		wanted[goName(local)]++
+	}
+	for m := c.Schemas.First(); m != nil; m = m.Next() {
+		_, local, _ := g.upstreamOf(m.Key())
+		name := goName(local)
+		if _, taken := g.names[name]; taken || wanted[name] > 1 {
+			name = goName(m.Key())
+		}
+		if _, taken := g.names[name]; taken {
+			name += "Schema"
+		}
+		if _, taken := g.names[name]; taken {
+			return fmt.Errorf("fail to name the type of schema '%s': '%s' is already declared", m.Key(), name)
+		}
+		g.names[name] = struct{}{}
+		g.schemas[m.Key()] = name
+	}
+	return nil
+}
+
+func (g *goGenerator) p(format string, args ...any) {
+	fmt.Fprintf(&g.b, format, args...)
+	g.b.WriteString("\n")
+}
+
+func (g *goGenerator) writeSchemas(c *v3.Components) {
+	if c == nil {
+		return
+	}
+	for m := c.Schemas.First(); m != nil; m = m.Next() {
+		name, sp := g.schemas[m.Key()], m.Value()
+		g.p("")
+		if prefix, local, ok := g.upstreamOf(m.Key()); ok {
+			g.p("// %s is the schema %s of the upstream %s.", name, local, prefix)
+		} else {
+			g.p("// %s is the schema %s.", name, m.Key())
+		}
+		s := sp.Schema()
+		if s != nil && s.Description != "" {
+			g.p("//")
+			g.b.WriteString(comment(s.Description))
+		}
+
+		switch t := g.goType(sp); {
+		case sp.IsReference():
+			g.p("type %s = %s", name, t)
+		case strings.HasPrefix(t, "struct"):
+			g.p("type %s %s", name, t)
+		case t == "string" && len(s.Enum) > 0:
+			g.p("type %s string", name)
+			g.p("")
+			g.p("const (")
+			for _, e := range s.Enum {
+				g.p("%s %s = %q", g.declare(name+goName(e.Value)), name, e.Value)
+			}
+			g.p(")")
+		default:
+			g.p("type %s = %s", name, t)
+		}
+	}
+}
+
+func (g *goGenerator) writeOperationTypes(op *goOperation) {
+	if op.ParamsType != "" {
+		g.p("")
+		g.p("// %s holds the parameters of %s.", op.ParamsType, op.Name)
+		g.p("type %s struct {", op.ParamsType)
+		for _, p := range op.Params {
+			t := p.Type
+			if !p.Required && !nilable(t) {
+				t = "*" + t
+			}
+			g.p("// %s is the %s parameter %s.", p.Field, p.In, p.Name)
+			g.p("%s %s", p.Field, t)
+		}
+		g.p("}")
+	}
+	if op.InjectType != "" {
+		g.p("")
+		g.p("// %s holds the parameters injected into the upstream request of %s.", op.InjectType, op.Name)
+		g.p("type %s struct {", op.InjectType)
+		for _, p := range op.Proxy.Inject {
+			g.p("// %s is the %s parameter %s.", p.Field, p.In, p.Name)
+			g.p("%s string", p.Field)
+		}
+		g.p("}")
+	}
+
+	g.p("")
+	g.p("// %s is the response of %s. The field matching StatusCode holds its JSON body, Body holds it raw.", op.ResponseType, op.Name)
+	g.p("type %s struct {", op.ResponseType)
+	g.p("StatusCode int")
+	g.p("Header http.Header")
+	g.p("Body []byte")
+	for _, r := range op.Responses {
+		if r.Type != "" {
+			g.p("%s *%s", r.Field, r.Type)
+		}
+	}
+	g.p("}")
+
+	g.p("")
+	g.p("func (r *%s) body() any {", op.ResponseType)
+	g.p("switch status := r.StatusCode; {")
+	for _, r := range op.Responses {
+		if r.Type != "" {
+			g.p("case %s && r.%s != nil:", responseCondition(r.Key), r.Field)
+			g.p("return r.%s", r.Field)
+		}
+	}
+	g.p("}")
+	g.p("return nil")
+	g.p("}")
+
+	g.p("")
+	g.p("func (r *%s) decode() error {", op.ResponseType)
+	g.p("if len(r.Body) == 0 || !isJSON(r.Header.Get(\"Content-Type\")) {")
+	g.p("return nil")
+	g.p("}")
+	g.p("switch status := r.StatusCode; {")
+	for _, r := range op.Responses {
+		if r.Type != "" {
+			g.p("case %s:", responseCondition(r.Key))
+			g.p("r.%s = new(%s)", r.Field, r.Type)
+			g.p("return json.Unmarshal(r.Body, r.%s)", r.Field)
+		}
+	}
+	g.p("}")
+	g.p("return nil")
+	g.p("}")
+}
+
+// signature returns the parameters of the Go functions implementing or calling op, after the context.
+func (op *goOperation) signature() string {
+	s := ""
+	if op.ParamsType != "" {
+		s += ", params " + op.ParamsType
+	}
+	if op.Body != nil {
+		s += ", body " + op.Body.Type
+	}
+	return s
+}
+
+func (op *goOperation) arguments() string {
+	s := ""
+	if op.ParamsType != "" {
+		s += ", params"
+	}
+	if op.Body != nil {
+		s += ", body"
+	}
+	return s
+}
+
+func (g *goGenerator) writeServer() {
+	g.p("")
+	g.p("// ServerInterface is implemented by the servers of the operations of the spec.")
+	g.p("type ServerInterface interface {")
+	for _, op := range g.ops {
+		g.p("// %s handles %s %s.", op.Name, op.Method, op.Path)
+		if op.Summary != "" {
+			g.b.WriteString(comment(op.Summary))
+		}
+		g.p("%s(ctx context.Context%s) (*%s, error)", op.Name, op.signature(), op.ResponseType)
+	}
+	g.p("}")
+
+	// paths without parameters take precedence over the templated ones they also match
+	routes := append([]*goOperation{}, g.ops...)
+	sort.SliceStable(routes, func(i, j int) bool {
+		return len(templateParam.FindAllString(routes[i].Path, -1)) < len(templateParam.FindAllString(routes[j].Path, -1))
+	})
+	g.p("")
+	g.p("// NewHandler returns an http.Handler decoding the requests of the operations of the spec for si to serve them.")
+	g.p("func NewHandler(si ServerInterface) http.Handler {")
+	g.p("return &handler{si: si, routes: []route{")
+	for _, op := range routes {
+		g.p("newRoute(%q, %q, (*handler).handle%s),", op.Method, op.Path, op.Name)
+	}
+	g.p("}}")
+	g.p("}")
+
+	for _, op := range g.ops {
+		g.p("")
+		g.p("func (h *handler) handle%s(w http.ResponseWriter, r *http.Request, path map[string]string) {", op.Name)
+		g.p("var errs []error")
+		if op.ParamsType != "" {
+			g.p("var params %s", op.ParamsType)
+			for _, p := range op.Params {
+				var values string
+				switch p.In {
+				case "path":
+					values = fmt.Sprintf("[]string{path[%q]}", p.Name)
+				case "query":
+					values = fmt.Sprintf("r.URL.Query()[%q]", p.Name)
+				case "header":
+					values = fmt.Sprintf("r.Header.Values(%q)", p.Name)
+				case "cookie":
+					values = fmt.Sprintf("cookieValues(r, %q)", p.Name)
+				}
+				g.p("if err := decodeParam(%s, &params.%s, %t); err != nil {", values, p.Field, p.Required)
+				g.p("errs = append(errs, fmt.Errorf(\"%s parameter '%s': %%w\", err))", p.In, p.Name)
+				g.p("}")
+			}
+		}
+		if op.Body != nil {
+			g.p("var body %s", op.Body.Type)
+			g.p("if err := decodeBody(r, &body, %t); err != nil {", op.Body.Required)
+			g.p("errs = append(errs, err)")
+			g.p("}")
+		}
+		g.p("if err := errors.Join(errs...); err != nil {")
+		g.p("writeError(w, http.StatusBadRequest, err)")
+		g.p("return")
+		g.p("}")
+		g.p("")
+		g.p("resp, err := h.si.%s(r.Context()%s)", op.Name, op.arguments())
+		g.p("if err != nil {")
+		g.p("writeError(w, http.StatusInternalServerError, err)")
+		g.p("return")
+		g.p("}")
+		g.p("writeResponse(w, resp.StatusCode, resp.Header, resp.body(), resp.Body)")
+		g.p("}")
+	}
+}
+
+func (g *goGenerator) writeClient() {
+	g.p("")
+	g.p("// Client calls the operations of the spec.")
+	g.p("type Client struct {")
+	g.p("Transport")
+	g.p("}")
+	for _, op := range g.ops {
+		g.p("")
+		g.p("// %s calls %s %s.", op.Name, op.Method, op.Path)
+		g.p("func (c *Client) %s(ctx context.Context%s) (*%s, error) {", op.Name, op.signature(), op.ResponseType)
+		g.writeCall(op, "c", op.Method, op.Path, op.Params, func(name string) (string, bool) {
+			return op.paramSource(name)
+		})
+		g.p("}")
+	}
+}
+
+// paramSource returns the expression holding the value of the path parameter of op with the given name.
+func (op *goOperation) paramSource(name string) (string, bool) {
+	for _, p := range op.Params {
+		if p.Name == name && p.In == "path" {
+			return "params." + p.Field, true
+		}
+	}
+	return "", false
+}
+
+// pathSource is like paramSource, but also looks into the parameters injected into the upstream request of op.
+func (op *goOperation) pathSource(name string) (string, bool) {
+	if s, ok := op.paramSource(name); ok {
+		return s, true
+	}
+	for _, p := range op.Proxy.Inject {
+		if p.Name == name && p.In == "path" {
+			return "inject." + p.Field, true
+		}
+	}
+	return "", false
+}
+
+var templateParam = regexp.MustCompile(`\{([^}/]+)\}`)
+
+// writeCall writes the body of a function sending the request of op through the Transport of recv to the given path
+// template, whose parameters are resolved by source, and returning its response.
+func (g *goGenerator) writeCall(op *goOperation, recv string, method string, template string, params []goParam, source func(name string) (string, bool)) {
+	path := []string{}
+	last := 0
+	for _, m := range templateParam.FindAllStringSubmatchIndex(template, -1) {
+		if template[last:m[0]] != "" {
+			path = append(path, strconv.Quote(template[last:m[0]]))
+		}
+		s, _ := source(template[m[2]:m[3]])
+		path = append(path, "pathValue("+s+")")
+		last = m[1]
+	}
+	if template[last:] != "" || len(path) == 0 {
+		path = append(path, strconv.Quote(template[last:]))
+	}
+
+	g.p("query, header := url.Values{}, http.Header{}")
+	for _, p := range params {
+		value := "params." + p.Field
+		if p.Value != "" || strings.HasPrefix(p.Field, "inject.") {
+			value = p.Field
+		}
+		switch p.In {
+		case "query":
+			g.p("addValues(query.Add, %q, %s)", p.Name, value)
+		case "header":
+			g.p("addValues(header.Add, %q, %s)", p.Name, value)
+		case "cookie":
+			g.p("addValues(func(k string, v string) { header.Add(\"Cookie\", k+\"=\"+v) }, %q, %s)", p.Name, value)
+		}
+	}
+	body, contentType := "nil", ""
+	if op.Body != nil {
+		body, contentType = "body", op.Body.ContentType
+	}
+	g.p("res, b, err := %s.send(ctx, %q, %s, query, header, %s, %q)", recv, method, strings.Join(path, "+"), body, contentType)
+	g.p("if err != nil {")
+	g.p("return nil, err")
+	g.p("}")
+	g.p("resp := &%s{StatusCode: res.StatusCode, Header: res.Header, Body: b}", op.ResponseType)
+	g.p("return resp, resp.decode()")
+}
+
+func (g *goGenerator) writeProxy() {
+	if len(g.upstream) == 0 {
+		return
+	}
+	var proxied, injected, direct bool
+	for _, op := range g.ops {
+		switch {
+		case op.Proxy == nil:
+			direct = true
+		case op.InjectType != "":
+			injected = true
+			proxied = true
+		default:
+			proxied = true
+		}
+	}
+	if !proxied {
+		return
+	}
+
+	for _, u := range g.upstream {
+		g.p("")
+		g.p("// %s calls the operations of the upstream %s that are proxied.", u.Type, u.Name)
+		if len(u.Security) > 0 {
+			var schemes []string
+			for _, s := range u.Security {
+				schemes = append(schemes, s.Upstream)
+			}
+			g.p("// Its requests are expected to be authenticated through RequestEditors with the security schemes %s.", strings.Join(schemes, ", "))
+		}
+		g.p("type %s struct {", u.Type)
+		g.p("Transport")
+		g.p("}")
+		for _, op := range g.ops {
+			if op.Proxy == nil || op.Proxy.Upstream != u {
+				continue
+			}
+			inject := ""
+			if op.InjectType != "" {
+				inject = ", inject " + op.InjectType
+			}
+			g.p("")
+			g.p("// %s forwards %s to %s %s.", op.Name, op.Name, op.Proxy.Method, op.Proxy.Path)
+			g.p("func (u *%s) %s(ctx context.Context%s%s) (*%s, error) {", u.Type, op.Name, op.signature(), inject, op.ResponseType)
+			params := append([]goParam{}, op.Params...)
+			for _, p := range op.Proxy.Inject {
+				p.Field = "inject." + p.Field
+				params = append(params, p)
+			}
+			g.writeCall(op, "u", op.Proxy.Method, op.Proxy.Path, params, op.pathSource)
+			g.p("}")
+		}
+	}
+
+	if injected {
+		g.p("")
+		g.p("// Injector completes the parameters injected into the upstream requests of the proxied operations,")
+		g.p("// which are initialized with the values set by the proxy spec, if any.")
+		g.p("type Injector interface {")
+		for _, op := range g.ops {
+			if op.InjectType == "" {
+				continue
+			}
+			params := ""
+			if op.ParamsType != "" {
+				params = ", params " + op.ParamsType
+			}
+			g.p("%s(ctx context.Context%s, inject *%s) error", op.Name, params, op.InjectType)
+		}
+		g.p("}")
+		g.p("")
+		g.p("// UnimplementedInjector keeps the values set by the proxy spec, it is meant to be embedded by the Injector")
+		g.p("// completing only some of the operations.")
+		g.p("type UnimplementedInjector struct{}")
+		for _, op := range g.ops {
+			if op.InjectType == "" {
+				continue
+			}
+			params := ""
+			if op.ParamsType != "" {
+				params = ", params " + op.ParamsType
+			}
+			g.p("")
+			g.p("func (UnimplementedInjector) %s(ctx context.Context%s, inject *%s) error {", op.Name, params, op.InjectType)
+			g.p("return nil")
+			g.p("}")
+		}
+	}
+
+	g.p("")
+	g.p("// Proxy is a ServerInterface forwarding every proxied operation to its upstream.")
+	g.p("type Proxy struct {")
+	if direct {
+		g.p("// ServerInterface serves the operations that are not proxied.")
+		g.p("ServerInterface")
+	}
+	if injected {
+		g.p("// Injector is optional, the injected parameters only get the values set by the proxy spec without it.")
+		g.p("Injector Injector")
+	}
+	for _, u := range g.upstream {
+		g.p("%s *%s", u.Field, u.Type)
+	}
+	g.p("}")
+	g.p("")
+	g.p("var _ ServerInterface = (*Proxy)(nil)")
+	g.p("")
+	g.p("// NewProxy returns a Proxy forwarding to the servers the upstreams are served at according to the proxy spec.")
+	g.p("func NewProxy() *Proxy {")
+	g.p("return &Proxy{")
+	for _, u := range g.upstream {
+		g.p("%s: &%s{Transport{Server: %q}},", u.Field, u.Type, u.Server)
+	}
+	g.p("}")
+	g.p("}")
+
+	for _, op := range g.ops {
+		if op.Proxy == nil {
+			continue
+		}
+		g.p("")
+		g.p("func (p *Proxy) %s(ctx context.Context%s) (*%s, error) {", op.Name, op.signature(), op.ResponseType)
+		args := op.arguments()
+		if op.InjectType != "" {
+			g.p("inject := %s{", op.InjectType)
+			for _, i := range op.Proxy.Inject {
+				if i.Value != "" {
+					g.p("%s: %q,", i.Field, i.Value)
+				}
+			}
+			g.p("}")
+			g.p("if p.Injector != nil {")
+			params := ""
+			if op.ParamsType != "" {
+				params = ", params"
+			}
+			g.p("if err := p.Injector.%s(ctx%s, &inject); err != nil {", op.Name, params)
+			g.p("return nil, err")
+			g.p("}")
+			g.p("}")
+			args += ", inject"
+		}
+		g.p("return p.%s.%s(ctx%s)", op.Proxy.Upstream.Field, op.Name, args)
+		g.p("}")
+	}
+}
+
+// goName converts s into an exported Go identifier, keeping the common initialisms upper case.
+func goName(s string) string {
+	b := strings.Builder{}
+	for _, w := range strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		if i, ok := initialisms[strings.ToLower(w)]; ok {
+			b.WriteString(i)
+			continue
+		}
+		r := []rune(w)
+		b.WriteRune(unicode.ToUpper(r[0]))
+		b.WriteString(string(r[1:]))
+	}
+	name := b.String()
+	if name != "" && unicode.IsDigit([]rune(name)[0]) {
+		name = "N" + name
+	}
+	return name
+}
+
+var initialisms = map[string]string{
+	"api": "API", "http": "HTTP", "id": "ID", "ip": "IP", "json": "JSON", "uri": "URI", "url": "URL", "uuid": "UUID",
+}
+
+// comment formats s as the lines of a Go comment.
+func comment(s string) string {
+	b := strings.Builder{}
+	for _, l := range strings.Split(strings.TrimSpace(s), "\n") {
+		b.WriteString(strings.TrimRight("// "+l, " ") + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,197 @@
+package codegen
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/stretchr/testify/require"
+	"github.com/telkomindonesia/openapi-utils/pkg/proxy"
+)
+
+func TestGenerateGo(t *testing.T) {
+	_, doc, err := proxy.Compile(context.Background(), "./testdata/spec-proxy.yml")
+	require.NoError(t, err)
+	b, err := GenerateGoDocument(doc, WithPackage("petapi"))
+	require.NoError(t, err)
+
+	s := string(b)
+	require.Contains(t, s, "// Code generated by openapi-utils. DO NOT EDIT.")
+	require.Contains(t, s, "package petapi")
+	require.Contains(t, s, "// Pet is the schema Pet of the upstream pet.")
+	require.Contains(t, s, "type ClientSchema struct", "schema should not conflict with the client")
+	require.Contains(t, s, `KindCat Kind = "cat"`)
+	require.Contains(t, s, "func (p *Proxy) GetPet(ctx context.Context, params GetPetParams) (*GetPetResponse, error)")
+	require.Contains(t, s, `Pet: &PetUpstream{Transport{Server: "https://pet:8443"}}`)
+
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is required to build the generated code")
+	}
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module petapi\n\ngo 1.21\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "api.go"), b, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "api_test.go"), []byte(forwardingTest), 0644))
+	cmd := exec.Command("go", "test", "./...")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func TestGenerateGoUnforwardable(t *testing.T) {
+	doc, err := libopenapi.NewDocument([]byte(`
+openapi: "3.0.0"
+info:
+  title: "Proxy API"
+  version: "1.0.0"
+paths:
+  /pets:
+    get:
+      operationId: ListPets
+      x-proxy:
+        name: pet
+        path: /tenants/{tenant-id}/pets
+        method: get
+      responses:
+        "200":
+          description: "success"
+`))
+	require.NoError(t, err)
+	_, err = GenerateGoDocument(doc)
+	require.ErrorContains(t, err, "upstream path parameter 'tenant-id' is neither a parameter nor injected")
+}
+
+func TestGenerateGoSchemaNames(t *testing.T) {
+	doc, err := libopenapi.NewDocument([]byte(`
+openapi: "3.0.0"
+info:
+  title: "Proxy API"
+  version: "1.0.0"
+paths:
+  /pets:
+    get:
+      operationId: ListPets
+      x-proxy:
+        name: pet
+        path: /pets
+        method: get
+      responses:
+        "200":
+          description: "success"
+components:
+  schemas:
+    Pet:
+      type: string
+    petPet:
+      type: string
+    petTag:
+      type: string
+    petTransport:
+      type: string
+`))
+	require.NoError(t, err)
+	b, err := GenerateGoDocument(doc)
+	require.NoError(t, err)
+
+	s := string(b)
+	require.Contains(t, s, "type Pet = string")
+	require.Contains(t, s, "type PetPet = string", "upstream schema should keep its prefix when conflicting with another schema")
+	require.Contains(t, s, "type Tag = string")
+	require.Contains(t, s, "type PetTransport = string", "upstream schema should keep its prefix when conflicting with the runtime")
+}
+
+// forwardingTest serves the generated Proxy in front of a fake upstream and calls it through the generated Client.
+const forwardingTest = `package petapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type health struct{ ServerInterface }
+
+func (health) GetHealth(ctx context.Context) (*GetHealthResponse, error) {
+	status := "ok"
+	return &GetHealthResponse{StatusCode: http.StatusOK, JSON200: &ClientSchema{Status: &status}}, nil
+}
+
+type injector struct{ UnimplementedInjector }
+
+func (injector) CreatePet(ctx context.Context, inject *CreatePetInject) error {
+	inject.TenantID = "globex"
+	return nil
+}
+
+func TestForwarding(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /tenants/acme/pets/7":
+			if r.Header.Get("X-Request-ID") != "r1" {
+				t.Errorf("unexpected header: %v", r.Header)
+			}
+			_ = json.NewEncoder(w).Encode(Pet{Name: "tom", Kind: KindCat})
+		case "GET /tenants/acme/pets":
+			if r.URL.RawQuery != "kind=dog" {
+				t.Errorf("unexpected query: %s", r.URL.RawQuery)
+			}
+			_ = json.NewEncoder(w).Encode([]Pet{{Name: "spike", Kind: KindDog}})
+		case "POST /tenants/globex/pets":
+			var p Pet
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(p)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(` + "`" + `{"message":"not found"}` + "`" + `))
+		}
+	}))
+	defer upstream.Close()
+
+	p := NewProxy()
+	p.Pet.Server = upstream.URL
+	p.ServerInterface = health{}
+	p.Injector = injector{}
+	front := httptest.NewServer(NewHandler(p))
+	defer front.Close()
+	c := &Client{Transport{Server: front.URL}}
+	ctx := context.Background()
+
+	requestID := "r1"
+	pet, err := c.GetPet(ctx, GetPetParams{PetID: 7, XRequestID: &requestID})
+	if err != nil || pet.StatusCode != http.StatusOK || pet.JSON200 == nil || pet.JSON200.Name != "tom" {
+		t.Fatalf("unexpected response: %+v %v", pet, err)
+	}
+
+	kind := KindDog
+	pets, err := c.ListPets(ctx, ListPetsParams{Kind: &kind})
+	if err != nil || pets.JSON200 == nil || len(*pets.JSON200) != 1 || (*pets.JSON200)[0].Name != "spike" {
+		t.Fatalf("unexpected response: %+v %v", pets, err)
+	}
+
+	created, err := c.CreatePet(ctx, Pet{Name: "jerry", Kind: KindCat})
+	if err != nil || created.StatusCode != http.StatusCreated || created.JSON201 == nil || created.JSON201.Name != "jerry" {
+		t.Fatalf("unexpected response: %+v %v", created, err)
+	}
+
+	missing, err := c.GetPet(ctx, GetPetParams{PetID: 8})
+	if err != nil || missing.StatusCode != http.StatusNotFound || missing.JSON4XX == nil || *missing.JSON4XX.Message != "not found" {
+		t.Fatalf("unexpected response: %+v %v", missing, err)
+	}
+
+	h, err := c.GetHealth(ctx)
+	if err != nil || h.JSON200 == nil || *h.JSON200.Status != "ok" {
+		t.Fatalf("unexpected response: %+v %v", h, err)
+	}
+
+	res, err := http.Get(front.URL + "/pets/abc")
+	if err != nil || res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unexpected response: %+v %v", res, err)
+	}
+}
+`
@@ -0,0 +1,26 @@
+package codegen
+
+import "log/slog"
+
+// Options holds the settings of the code generation.
+type Options struct {
+	// Logger receives warnings emitted while bundling the spec.
+	Logger *slog.Logger
+	// Package is the name of the package of the generated Go code, `api` when empty.
+	Package string
+}
+
+type Option func(*Options)
+
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+// WithPackage sets the name of the package of the generated Go code, see Options.Package.
+func WithPackage(name string) Option {
+	return func(o *Options) {
+		o.Package = name
+	}
+}
@@ -0,0 +1,301 @@
+package codegen
+
+// runtimeIdentifiers lists the package-level identifiers declared by goRuntime, which generated identifiers must avoid.
+var runtimeIdentifiers = []string{
+	"RequestEditor", "Transport", "send", "handler", "route", "newRoute", "isJSON",
+	"parseParam", "decodeParam", "addValues", "pathValue", "cookieValues", "decodeBody", "writeResponse", "writeError",
+}
+
+// goRuntime holds the helpers every generated Go file relies on.
+const goRuntime = `
+// RequestEditor changes a request before a Transport sends it, e.g. to authenticate it.
+type RequestEditor func(ctx context.Context, req *http.Request) error
+
+// Transport sends the requests of a client to a server.
+type Transport struct {
+	// Server is the base URL of the server, e.g. https://example.com/api.
+	Server string
+	// HTTPClient sends the requests, http.DefaultClient is used when it is nil.
+	HTTPClient     *http.Client
+	RequestEditors []RequestEditor
+}
+
+// send sends a request whose body is encoded as JSON unless it is a []byte, and returns the response with its body read.
+func (t *Transport) send(ctx context.Context, method string, path string, query url.Values, header http.Header, body any, contentType string) (*http.Response, []byte, error) {
+	u := strings.TrimSuffix(t.Server, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var r io.Reader
+	if v := reflect.ValueOf(body); body != nil && (v.Kind() != reflect.Pointer || !v.IsNil()) {
+		b, ok := body.([]byte)
+		if !ok {
+			var err error
+			if b, err = json.Marshal(body); err != nil {
+				return nil, nil, fmt.Errorf("fail to encode request body: %w", err)
+			}
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fail to create request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if r != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for _, edit := range t.RequestEditors {
+		if err = edit(ctx, req); err != nil {
+			return nil, nil, fmt.Errorf("fail to edit request: %w", err)
+		}
+	}
+
+	c := t.HTTPClient
+	if c == nil {
+		c = http.DefaultClient
+	}
+	res, err := c.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fail to read response body: %w", err)
+	}
+	return res, b, nil
+}
+
+type handler struct {
+	si     ServerInterface
+	routes []route
+}
+
+type route struct {
+	method  string
+	pattern *regexp.Regexp
+	params  []string
+	handle  func(h *handler, w http.ResponseWriter, r *http.Request, path map[string]string)
+}
+
+func newRoute(method string, template string, handle func(h *handler, w http.ResponseWriter, r *http.Request, path map[string]string)) route {
+	rt := route{method: method, handle: handle}
+	b := strings.Builder{}
+	b.WriteString("^")
+	last := 0
+	for _, m := range regexp.MustCompile("\\{([^}/]+)\\}").FindAllStringSubmatchIndex(template, -1) {
+		b.WriteString(regexp.QuoteMeta(template[last:m[0]]))
+		b.WriteString("([^/]+)")
+		rt.params = append(rt.params, template[m[2]:m[3]])
+		last = m[1]
+	}
+	b.WriteString(regexp.QuoteMeta(template[last:]))
+	b.WriteString("$")
+	rt.pattern = regexp.MustCompile(b.String())
+	return rt
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var allowed []string
+	for _, rt := range h.routes {
+		m := rt.pattern.FindStringSubmatch(r.URL.EscapedPath())
+		if m == nil {
+			continue
+		}
+		if rt.method != r.Method {
+			allowed = append(allowed, rt.method)
+			continue
+		}
+		path := make(map[string]string, len(rt.params))
+		for i, name := range rt.params {
+			v, err := url.PathUnescape(m[i+1])
+			if err != nil {
+				v = m[i+1]
+			}
+			path[name] = v
+		}
+		rt.handle(h, w, r, path)
+		return
+	}
+	if len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+		return
+	}
+	writeError(w, http.StatusNotFound, fmt.Errorf("path %s is not found", r.URL.Path))
+}
+
+func isJSON(contentType string) bool {
+	t, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (t == "application/json" || strings.HasSuffix(t, "+json"))
+}
+
+// parseParam parses s into dst, a pointer to a string, a number, a boolean or a time.Time.
+func parseParam(s string, dst reflect.Value) error {
+	if _, ok := dst.Interface().(time.Time); ok {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return err
+		}
+		dst.Set(reflect.ValueOf(t))
+		return nil
+	}
+	switch dst.Kind() {
+	case reflect.String:
+		dst.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetInt(i)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		dst.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported type %s", dst.Type())
+	}
+	return nil
+}
+
+// decodeParam parses the values of a parameter into dst, a pointer to a value, to a pointer or to a slice.
+func decodeParam(values []string, dst any, required bool) error {
+	if len(values) == 0 || (len(values) == 1 && values[0] == "") {
+		if required {
+			return errors.New("missing required value")
+		}
+		return nil
+	}
+
+	v := reflect.ValueOf(dst).Elem()
+	switch v.Kind() {
+	case reflect.Pointer:
+		e := reflect.New(v.Type().Elem())
+		if err := parseParam(values[0], e.Elem()); err != nil {
+			return err
+		}
+		v.Set(e)
+	case reflect.Slice:
+		if len(values) == 1 {
+			values = strings.Split(values[0], ",")
+		}
+		s := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := parseParam(value, s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	default:
+		return parseParam(values[0], v)
+	}
+	return nil
+}
+
+// addValues adds the formatted values of v, which may be a pointer or a slice, under the given name.
+func addValues(add func(key string, value string), name string, v any) {
+	rv := reflect.ValueOf(v)
+	switch {
+	case rv.Kind() == reflect.Pointer && rv.IsNil():
+		return
+	case rv.Kind() == reflect.Pointer:
+		rv = rv.Elem()
+	case rv.Kind() == reflect.Slice:
+		for i := 0; i < rv.Len(); i++ {
+			addValues(add, name, rv.Index(i).Interface())
+		}
+		return
+	}
+	if t, ok := rv.Interface().(time.Time); ok {
+		add(name, t.Format(time.RFC3339))
+		return
+	}
+	add(name, fmt.Sprint(rv.Interface()))
+}
+
+// pathValue formats v as a segment of a path.
+func pathValue(v any) string {
+	var s []string
+	addValues(func(_ string, value string) { s = append(s, value) }, "", v)
+	return url.PathEscape(strings.Join(s, ","))
+}
+
+func cookieValues(r *http.Request, name string) []string {
+	c, err := r.Cookie(name)
+	if err != nil {
+		return nil
+	}
+	return []string{c.Value}
+}
+
+// decodeBody decodes the JSON body of r into dst, or reads it when dst is a *[]byte.
+func decodeBody(r *http.Request, dst any, required bool) error {
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("fail to read request body: %w", err)
+	}
+	if len(b) == 0 {
+		if required {
+			return errors.New("missing required request body")
+		}
+		return nil
+	}
+	if raw, ok := dst.(*[]byte); ok {
+		*raw = b
+		return nil
+	}
+	if v := reflect.ValueOf(dst).Elem(); v.Kind() == reflect.Pointer {
+		v.Set(reflect.New(v.Type().Elem()))
+		dst = v.Interface()
+	}
+	if err = json.Unmarshal(b, dst); err != nil {
+		return fmt.Errorf("invalid JSON request body: %w", err)
+	}
+	return nil
+}
+
+// writeResponse writes body as JSON, or raw when it is nil.
+func writeResponse(w http.ResponseWriter, status int, header http.Header, body any, raw []byte) {
+	for k, v := range header {
+		w.Header()[k] = v
+	}
+	// the body may be encoded differently from the one the header was received with
+	w.Header().Del("Content-Length")
+	w.Header().Del("Transfer-Encoding")
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if body == nil {
+		w.WriteHeader(status)
+		_, _ = w.Write(raw)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"title": err.Error(), "status": status})
+}
+`
+
+// goImports lists the packages imported by every generated Go file.
+var goImports = []string{
+	"bytes", "context", "encoding/json", "errors", "fmt", "io", "mime", "net/http", "net/url", "reflect", "regexp", "strconv", "strings", "time",
+}
@@ -0,0 +1,15 @@
+// Package docs renders the reference documentation of an OpenAPI 3 spec.
+//
+// RenderHTML renders a static multi-page HTML site: an index listing the servers, tags and schemas of the
+// spec, a page per tag holding its operations with their parameter tables, request and response bodies and
+// examples, and a page per schema component. Schemas are rendered as trees whose references link to the
+// page of the component they point to. Media types declaring no example get one generated from their schema.
+//
+// RenderMarkdown renders the same reference as Markdown documents, one per tag or a single one, where schemas
+// are rendered as nested lists. Given the ProxyExtension a spec is compiled from, the proxied operations of both
+// renderings are annotated with the upstream operation they are forwarded to.
+//
+// The pages are rendered with html/template, and the Markdown documents with text/template, from the Site
+// model. Any of the default templates may be replaced through WithTemplates, see Options.Templates for their
+// names.
+package docs
@@ -0,0 +1,138 @@
+package docs
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"strings"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/telkomindonesia/openapi-utils/pkg/bundler"
+)
+
+//go:embed templates
+var defaultTemplates embed.FS
+
+// File is a file of the rendered documentation.
+type File struct {
+	// Name is the slash-separated path of the file relative to the root of the documentation.
+	Name    string
+	Content []byte
+}
+
+// Page is the data given to the template of a page. Tag is only set on the page of a tag and Component on
+// the page of a schema component.
+type Page struct {
+	Site      *Site
+	Title     string
+	Tag       *Tag
+	Component *Component
+}
+
+// RenderHTML renders the documentation of the spec at the given path, bundled by bundler.BundleDocumentAt,
+// as a static HTML site, see RenderHTMLDocument.
+func RenderHTML(ctx context.Context, specPath string, opts ...Option) ([]File, error) {
+	doc, err := bundler.BundleDocumentAt(ctx, specPath, bundler.WithLogger(newOptions(opts).Logger))
+	if err != nil {
+		return nil, err
+	}
+	return RenderHTMLDocument(doc, opts...)
+}
+
+// RenderHTMLDocument renders the documentation of doc, whose references are expected to be local, such as
+// the ones of a bundled spec or of a compiled proxy spec, as a static HTML site. The site is made of
+// `index.html`, `tag-<slug>.html` for every tag, `schema-<slug>.html` for every schema component and
+// `style.css`, all linked to each other relatively.
+func RenderHTMLDocument(doc libopenapi.Document, opts ...Option) ([]File, error) {
+	o := newOptions(opts)
+	site, err := NewSite(doc, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	tagPage := func(t *Tag) string { return "tag-" + t.Slug + ".html" }
+	componentPage := func(c *Component) string { return "schema-" + c.Slug + ".html" }
+	base := template.New("").Funcs(template.FuncMap{
+		"lower":   strings.ToLower,
+		"tagPage": tagPage,
+		// schemaPage returns the page of the schema component of the given name, see Schema.Ref
+		"schemaPage": func(name string) string {
+			if c := site.Component(name); c != nil {
+				return componentPage(c)
+			}
+			return ""
+		},
+	})
+	for _, name := range []string{"layout.html", "partials.html"} {
+		text, err := readTemplate(o, name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = base.New(name).Parse(text); err != nil {
+			return nil, fmt.Errorf("fail to parse template %s: %w", name, err)
+		}
+	}
+	render := func(name string, page Page) ([]byte, error) {
+		text, err := readTemplate(o, name)
+		if err != nil {
+			return nil, err
+		}
+		t, err := base.Clone()
+		if err != nil {
+			return nil, fmt.Errorf("fail to clone templates: %w", err)
+		}
+		if _, err = t.New(name).Parse(text); err != nil {
+			return nil, fmt.Errorf("fail to parse template %s: %w", name, err)
+		}
+		var buf bytes.Buffer
+		if err = t.ExecuteTemplate(&buf, name, page); err != nil {
+			return nil, fmt.Errorf("fail to render template %s: %w", name, err)
+		}
+		return buf.Bytes(), nil
+	}
+
+	b, err := render("index.html", Page{Site: site})
+	if err != nil {
+		return nil, err
+	}
+	files := []File{{Name: "index.html", Content: b}}
+	for _, t := range site.Tags {
+		if b, err = render("tag.html", Page{Site: site, Title: t.Name, Tag: t}); err != nil {
+			return nil, err
+		}
+		files = append(files, File{Name: tagPage(t), Content: b})
+	}
+	for _, c := range site.Schemas {
+		if b, err = render("schema.html", Page{Site: site, Title: c.Name, Component: c}); err != nil {
+			return nil, err
+		}
+		files = append(files, File{Name: componentPage(c), Content: b})
+	}
+	css, err := readTemplate(o, "style.css")
+	if err != nil {
+		return nil, err
+	}
+	return append(files, File{Name: "style.css", Content: []byte(css)}), nil
+}
+
+// readTemplate returns the template of the given name from Options.Templates, or the default one.
+func readTemplate(o Options, name string) (string, error) {
+	if o.Templates != nil {
+		b, err := fs.ReadFile(o.Templates, name)
+		switch {
+		case err == nil:
+			return string(b), nil
+		case !errors.Is(err, fs.ErrNotExist):
+			return "", fmt.Errorf("fail to read template %s: %w", name, err)
+		}
+	}
+	b, err := defaultTemplates.ReadFile("templates/" + name)
+	if err != nil {
+		return "", fmt.Errorf("fail to read default template %s: %w", name, err)
+	}
+	return string(b), nil
+}
@@ -0,0 +1,65 @@
+package docs
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestRenderHTML(t *testing.T) {
+	files, err := RenderHTML(context.Background(), "./testdata/spec.yml")
+	require.NoError(t, err)
+
+	assertGolden(t, filepath.Join("testdata", "golden", "html"), files)
+}
+
+// assertGolden compares files with the content of the golden directory dir,
+// which is replaced by files first when the -update flag is set.
+func assertGolden(t *testing.T, dir string, files []File) {
+	t.Helper()
+	if *update {
+		require.NoError(t, os.RemoveAll(dir))
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		for _, f := range files {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, f.Name), f.Content, 0644))
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, len(entries))
+	for _, f := range files {
+		expected, err := os.ReadFile(filepath.Join(dir, f.Name))
+		require.NoError(t, err)
+		require.Equal(t, string(expected), string(f.Content), f.Name)
+	}
+}
+
+func TestRenderHTMLTemplates(t *testing.T) {
+	templates := fstest.MapFS{
+		"index.html": {Data: []byte(`{{template "layout" .}}{{define "main"}}<h1>{{.Site.Title}} reference</h1>{{end}}`)},
+		"style.css":  {Data: []byte("body { color: red; }\n")},
+	}
+	files, err := RenderHTML(context.Background(), "./testdata/spec.yml", WithTemplates(templates))
+	require.NoError(t, err)
+
+	rendered := map[string]string{}
+	for _, f := range files {
+		rendered[f.Name] = string(f.Content)
+	}
+	require.Contains(t, rendered["index.html"], "<h1>Pet API reference</h1>")
+	require.Contains(t, rendered["index.html"], `<a href="tag-pets.html">pets</a>`, "default layout should be kept")
+	require.Equal(t, "body { color: red; }\n", rendered["style.css"])
+	require.Contains(t, rendered["schema-pet.html"], `<a href="schema-owner.html">Owner</a>`)
+
+	_, err = RenderHTML(context.Background(), "./testdata/spec.yml", WithTemplates(fstest.MapFS{
+		"tag.html": {Data: []byte(`{{template "layout" .}}{{define "main"}}{{.Tag.Missing}}{{end}}`)},
+	}))
+	require.ErrorContains(t, err, "fail to render template tag.html")
+}
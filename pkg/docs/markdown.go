@@ -0,0 +1,192 @@
+package docs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/telkomindonesia/openapi-utils/pkg/bundler"
+)
+
+// RenderMarkdown renders the reference of the spec at the given path, bundled by bundler.BundleDocumentAt,
+// as Markdown, see RenderMarkdownDocument.
+func RenderMarkdown(ctx context.Context, specPath string, opts ...Option) ([]File, error) {
+	doc, err := bundler.BundleDocumentAt(ctx, specPath, bundler.WithLogger(newOptions(opts).Logger))
+	if err != nil {
+		return nil, err
+	}
+	return RenderMarkdownDocument(doc, opts...)
+}
+
+// RenderMarkdownDocument renders the reference of doc, whose references are expected to be local, such as the
+// ones of a bundled spec or of a compiled proxy spec, as Markdown. The reference is made of `index.md`,
+// `tag-<slug>.md` for every tag and `schemas.md` holding every schema component, or only of `index.md`
+// holding everything when Options.SingleFile is set. Schemas are rendered as nested lists.
+func RenderMarkdownDocument(doc libopenapi.Document, opts ...Option) ([]File, error) {
+	o := newOptions(opts)
+	site, err := NewSite(doc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	text, err := readTemplate(o, "markdown.md")
+	if err != nil {
+		return nil, err
+	}
+
+	tagPage := func(t *Tag) string { return "tag-" + t.Slug + ".md" }
+	render := func(name string, file string, page Page) ([]byte, error) {
+		// links are relative to the file being rendered
+		link := func(f string, anchor string) string {
+			if o.SingleFile || f == file {
+				return "#" + anchor
+			}
+			return f + "#" + anchor
+		}
+		md := markdown{link: func(name string) string {
+			if c := site.Component(name); c != nil {
+				return link("schemas.md", "schema-"+c.Slug)
+			}
+			return ""
+		}}
+		t, err := template.New("markdown.md").Funcs(template.FuncMap{
+			"cell":       cell,
+			"schemaLink": md.link,
+			"schemaList": md.schemaList,
+			"schemaType": md.schemaType,
+			"tagLink":    func(t *Tag) string { return link(tagPage(t), "tag-"+t.Slug) },
+			"operationLink": func(t *Tag, op *Operation) string {
+				return link(tagPage(t), op.Anchor)
+			},
+		}).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("fail to parse template markdown.md: %w", err)
+		}
+		var buf bytes.Buffer
+		if err = t.ExecuteTemplate(&buf, name, page); err != nil {
+			return nil, fmt.Errorf("fail to render template %s: %w", name, err)
+		}
+		return buf.Bytes(), nil
+	}
+
+	if o.SingleFile {
+		b, err := render("single", "index.md", Page{Site: site})
+		if err != nil {
+			return nil, err
+		}
+		return []File{{Name: "index.md", Content: b}}, nil
+	}
+
+	b, err := render("index", "index.md", Page{Site: site})
+	if err != nil {
+		return nil, err
+	}
+	files := []File{{Name: "index.md", Content: b}}
+	for _, t := range site.Tags {
+		if b, err = render("tag", tagPage(t), Page{Site: site, Title: t.Name, Tag: t}); err != nil {
+			return nil, err
+		}
+		files = append(files, File{Name: tagPage(t), Content: b})
+	}
+	if len(site.Schemas) > 0 {
+		if b, err = render("schemas", "schemas.md", Page{Site: site, Title: "Schemas"}); err != nil {
+			return nil, err
+		}
+		files = append(files, File{Name: "schemas.md", Content: b})
+	}
+	return files, nil
+}
+
+// markdown renders schema trees as nested Markdown lists.
+type markdown struct {
+	// link returns the link to the schema component of the given name.
+	link func(name string) string
+}
+
+// schemaList renders s as a nested list whose items are indented by two spaces per level.
+func (m markdown) schemaList(s *Schema) string {
+	var b strings.Builder
+	m.schemaItem(&b, s, "", 0)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (m markdown) schemaItem(b *strings.Builder, s *Schema, label string, depth int) {
+	b.WriteString(strings.Repeat("  ", depth) + "- ")
+	if label != "" {
+		b.WriteString(label + " ")
+	}
+	b.WriteString(m.schemaType(s))
+	for _, f := range []struct {
+		set  bool
+		text string
+	}{
+		{s.Required, " **required**"},
+		{s.Nullable, " _nullable_"},
+		{s.ReadOnly, " _read-only_"},
+		{s.WriteOnly, " _write-only_"},
+		{s.Deprecated, " _deprecated_"},
+	} {
+		if f.set {
+			b.WriteString(f.text)
+		}
+	}
+	if d := strings.TrimSpace(s.Title + " " + s.Description); d != "" {
+		b.WriteString(": " + inline(d))
+	}
+	if len(s.Enum) > 0 {
+		b.WriteString(" One of: `" + strings.Join(s.Enum, "`, `") + "`.")
+	}
+	if s.Default != "" {
+		b.WriteString(" Default: `" + inline(s.Default) + "`.")
+	}
+	if len(s.Constraints) > 0 {
+		b.WriteString(" Constraints: `" + strings.Join(s.Constraints, "`, `") + "`.")
+	}
+	b.WriteString("\n")
+
+	for _, p := range s.Properties {
+		m.schemaItem(b, p, "`"+p.Name+"`", depth+1)
+	}
+	if s.Items != nil {
+		m.schemaItem(b, s.Items, "_items_", depth+1)
+	}
+	if s.AdditionalProperties != nil {
+		m.schemaItem(b, s.AdditionalProperties, "_values_", depth+1)
+	}
+	for _, v := range s.Variants {
+		m.schemaItem(b, v, "_"+s.Composition+"_", depth+1)
+	}
+}
+
+// schemaType renders the type of s, or the link to the schema component it references.
+func (m markdown) schemaType(s *Schema) string {
+	switch {
+	case s.Ref != "" && m.link(s.Ref) != "":
+		return "[" + s.Ref + "](" + m.link(s.Ref) + ")"
+	case s.Ref != "":
+		return "`" + s.Ref + "`"
+	}
+	t := s.Type
+	if t == "" {
+		t = s.Composition
+	}
+	if t == "" {
+		t = "any"
+	}
+	if s.Format != "" {
+		return "`" + t + "` (" + s.Format + ")"
+	}
+	return "`" + t + "`"
+}
+
+// inline joins the lines of s so that it fits in a list item.
+func inline(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// cell escapes s for a cell of a Markdown table.
+func cell(s string) string {
+	return strings.ReplaceAll(inline(s), "|", `\|`)
+}
@@ -0,0 +1,30 @@
+package docs
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/telkomindonesia/openapi-utils/pkg/proxy"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	ctx := context.Background()
+	pe, err := proxy.NewProxyExtension(ctx, "./testdata/spec-proxy.yml")
+	require.NoError(t, err)
+	_, doc, _, err := pe.CreateProxyDoc()
+	require.NoError(t, err)
+	proxied, err := RenderMarkdownDocument(doc, WithProxyExtension(&pe), WithSingleFile(true))
+	require.NoError(t, err)
+	require.Len(t, proxied, 1)
+
+	files, err := RenderMarkdown(ctx, "./testdata/spec.yml")
+	require.NoError(t, err)
+
+	for name, files := range map[string][]File{"markdown": files, "markdown-proxy": proxied} {
+		t.Run(name, func(t *testing.T) {
+			assertGolden(t, filepath.Join("testdata", "golden", name), files)
+		})
+	}
+}
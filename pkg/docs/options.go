@@ -0,0 +1,61 @@
+package docs
+
+import (
+	"io/fs"
+	"log/slog"
+
+	"github.com/telkomindonesia/openapi-utils/pkg/proxy"
+)
+
+// Options holds the settings of the documentation rendering.
+type Options struct {
+	// Logger receives warnings emitted while bundling the spec.
+	Logger *slog.Logger
+	// Templates holds the files replacing the default templates of the same name: `layout.html` defining
+	// the `layout` template which renders the `main` template of a page, `partials.html` defining the
+	// templates shared by the pages, the page templates `index.html`, `tag.html` and `schema.html`
+	// executed with a Page, `style.css` copied as is, and `markdown.md` defining the `index`, `tag`,
+	// `schemas` and `single` templates of the Markdown documents.
+	Templates fs.FS
+	// ProxyExtension is the proxy spec the documented spec is compiled from. The operations it proxies are
+	// annotated with their upstream, see Operation.Upstream.
+	ProxyExtension *proxy.ProxyExtension
+	// SingleFile renders the Markdown reference as a single document instead of one per tag.
+	SingleFile bool
+}
+
+type Option func(*Options)
+
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+// WithTemplates replaces the default templates by the ones of fsys, see Options.Templates.
+func WithTemplates(fsys fs.FS) Option {
+	return func(o *Options) {
+		o.Templates = fsys
+	}
+}
+
+// WithProxyExtension annotates the proxied operations with their upstream, see Options.ProxyExtension.
+func WithProxyExtension(pe *proxy.ProxyExtension) Option {
+	return func(o *Options) {
+		o.ProxyExtension = pe
+	}
+}
+
+// WithSingleFile renders the Markdown reference as a single document, see Options.SingleFile.
+func WithSingleFile(single bool) Option {
+	return func(o *Options) {
+		o.SingleFile = single
+	}
+}
+
+func newOptions(opts []Option) (o Options) {
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return
+}
@@ -0,0 +1,528 @@
+package docs
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"github.com/telkomindonesia/openapi-utils/internal/util"
+	"github.com/telkomindonesia/openapi-utils/pkg/proxy"
+	"gopkg.in/yaml.v3"
+)
+
+// Site is the documentation model of a spec given to the templates.
+type Site struct {
+	Title       string
+	Version     string
+	Description string
+	Servers     []*Server
+	// Tags holds the tags in the order the spec declares them, followed by the ones only used by operations.
+	// Operations without tags are grouped under the `default` tag.
+	Tags []*Tag
+	// Schemas holds the schema components in the order the spec declares them.
+	Schemas []*Component
+}
+
+type Server struct {
+	URL         string
+	Description string
+}
+
+type Tag struct {
+	Name        string
+	Description string
+	// Slug is the name of the tag usable in file names and anchors, unique among the tags.
+	Slug       string
+	Operations []*Operation
+}
+
+type Operation struct {
+	ID          string
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+	Deprecated  bool
+	// Anchor identifies the operation inside the page of its tags.
+	Anchor string
+	// Upstream is the upstream operation the operation is forwarded to, only set for the operations of a
+	// compiled proxy spec given with WithProxyExtension.
+	Upstream *Upstream
+	// Parameters holds the parameters of the operation followed by the ones of the path item it does not override.
+	Parameters  []*Parameter
+	RequestBody *RequestBody
+	Responses   []*Response
+}
+
+// Upstream is the origin of a proxied operation.
+type Upstream struct {
+	// Name is the name of the proxy, which prefixes the components copied from its spec.
+	Name   string
+	Method string
+	Path   string
+	// Server is the URL of the upstream server selected for the environment, if any.
+	Server string
+}
+
+type Parameter struct {
+	Name        string
+	In          string
+	Description string
+	Required    bool
+	Deprecated  bool
+	Schema      *Schema
+	// Example is the example declared by the parameter or by its schema, rendered as JSON.
+	Example string
+}
+
+type RequestBody struct {
+	Description string
+	Required    bool
+	Content     []*MediaType
+}
+
+type Response struct {
+	// Code is the status code of the response, or `default`.
+	Code        string
+	Description string
+	Headers     []*Parameter
+	Content     []*MediaType
+}
+
+type MediaType struct {
+	Type     string
+	Schema   *Schema
+	Examples []*Example
+}
+
+type Example struct {
+	Name    string
+	Summary string
+	// Value is the example rendered as indented JSON.
+	Value string
+	// Generated tells the example is generated from the schema since the media type declares none.
+	Generated bool
+}
+
+// Component is a schema declared under `components/schemas`.
+type Component struct {
+	Name string
+	// Slug is the name of the component usable in file names and anchors, unique among the components.
+	Slug    string
+	Schema  *Schema
+	Example *Example
+}
+
+// Schema is a node of a schema tree. References to schema components are not expanded, the node then only
+// holds the name of the component in Ref.
+type Schema struct {
+	// Name is the name of the property, empty for the root of the tree.
+	Name string
+	// Ref is the name of the referenced schema component, see Site.Component.
+	Ref         string
+	Type        string
+	Format      string
+	Title       string
+	Description string
+	Required    bool
+	Nullable    bool
+	ReadOnly    bool
+	WriteOnly   bool
+	Deprecated  bool
+	Enum        []string
+	Default     string
+	// Constraints holds the validation keywords of the schema such as `minimum: 1`.
+	Constraints []string
+	Properties  []*Schema
+	Items       *Schema
+	// AdditionalProperties is the schema of the values of a map.
+	AdditionalProperties *Schema
+	// Composition is either `allOf`, `oneOf` or `anyOf` when the schema combines the ones of Variants.
+	Composition string
+	Variants    []*Schema
+}
+
+// Proxied tells whether any operation of the tag is forwarded to an upstream.
+func (t *Tag) Proxied() bool {
+	for _, o := range t.Operations {
+		if o.Upstream != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Component returns the schema component of the given name, or nil.
+func (s *Site) Component(name string) *Component {
+	for _, c := range s.Schemas {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// maxDepth bounds the depth of the inline schemas expanded in a tree, guarding against recursive schemas
+// which do not go through a component.
+const maxDepth = 16
+
+const schemaPrefix = "#/components/schemas/"
+
+// NewSite returns the documentation model of doc, whose references are expected to be local, such as the
+// ones of a bundled spec or of a compiled proxy spec.
+func NewSite(doc libopenapi.Document, opts ...Option) (*Site, error) {
+	o := newOptions(opts)
+	upstreams := proxiedUpstreams(o.ProxyExtension)
+	docv3, err := util.BuildV3Model(doc)
+	if err != nil {
+		return nil, fmt.Errorf("fail to build v3 model: %w", err)
+	}
+	m := &docv3.Model
+
+	s := &Site{}
+	if m.Info != nil {
+		s.Title, s.Version, s.Description = m.Info.Title, m.Info.Version, m.Info.Description
+	}
+	for _, srv := range m.Servers {
+		s.Servers = append(s.Servers, &Server{URL: srv.URL, Description: srv.Description})
+	}
+
+	tags := map[string]*Tag{}
+	tagSlugs := slugs{}
+	tag := func(name string) *Tag {
+		if t, ok := tags[name]; ok {
+			return t
+		}
+		t := &Tag{Name: name, Slug: tagSlugs.unique(name)}
+		tags[name] = t
+		s.Tags = append(s.Tags, t)
+		return t
+	}
+	for _, t := range m.Tags {
+		tag(t.Name).Description = t.Description
+	}
+
+	if m.Paths != nil {
+		anchors := slugs{}
+		for p := m.Paths.PathItems.First(); p != nil; p = p.Next() {
+			for _, method := range util.Methods {
+				op := util.GetOperation(p.Value(), method)
+				if op == nil {
+					continue
+				}
+				o := newOperation(p.Key(), method, p.Value(), op)
+				o.Anchor = anchors.unique(o.ID)
+				o.Upstream = upstreams[p.Key()+" "+method]
+				names := op.Tags
+				if len(names) == 0 {
+					names = []string{"default"}
+				}
+				for _, name := range names {
+					t := tag(name)
+					t.Operations = append(t.Operations, o)
+				}
+			}
+		}
+	}
+
+	if m.Components != nil {
+		componentSlugs := slugs{}
+		for c := m.Components.Schemas.First(); c != nil; c = c.Next() {
+			sc := c.Value().Schema()
+			s.Schemas = append(s.Schemas, &Component{
+				Name:    c.Key(),
+				Slug:    componentSlugs.unique(c.Key()),
+				Schema:  newSchema(c.Value(), "", false, 0),
+				Example: schemaExample(sc),
+			})
+		}
+	}
+	return s, nil
+}
+
+// proxiedUpstreams returns the upstream of every proxied operation of pe keyed by its path and method.
+func proxiedUpstreams(pe *proxy.ProxyExtension) map[string]*Upstream {
+	upstreams := map[string]*Upstream{}
+	if pe == nil || pe.GetOpenAPIV3Doc().Model.Paths == nil {
+		return upstreams
+	}
+	proxied := pe.Proxied()
+	for p := pe.GetOpenAPIV3Doc().Model.Paths.PathItems.First(); p != nil; p = p.Next() {
+		for _, method := range util.Methods {
+			pop, ok := proxied[util.GetOperation(p.Value(), method)]
+			if !ok {
+				continue
+			}
+			upstreams[p.Key()+" "+method] = &Upstream{
+				Name:   pop.GetName(),
+				Method: strings.ToUpper(pop.Method),
+				Path:   pop.Path,
+				Server: pop.GetUpstreamServerURL(),
+			}
+		}
+	}
+	return upstreams
+}
+
+func newOperation(path string, method string, item *v3.PathItem, op *v3.Operation) *Operation {
+	o := &Operation{
+		ID:          op.OperationId,
+		Method:      strings.ToUpper(method),
+		Path:        path,
+		Summary:     op.Summary,
+		Description: op.Description,
+		Deprecated:  op.Deprecated != nil && *op.Deprecated,
+	}
+	if o.ID == "" {
+		o.ID = method + path
+	}
+
+	for _, p := range util.CopyParameters(op.Parameters, item.Parameters...) {
+		o.Parameters = append(o.Parameters, newParameter(p))
+	}
+	if rb := op.RequestBody; rb != nil {
+		o.RequestBody = &RequestBody{
+			Description: rb.Description,
+			Required:    rb.Required != nil && *rb.Required,
+			Content:     newContent(rb.Content),
+		}
+	}
+	if op.Responses != nil {
+		for r := op.Responses.Codes.First(); r != nil; r = r.Next() {
+			o.Responses = append(o.Responses, newResponse(r.Key(), r.Value()))
+		}
+		if op.Responses.Default != nil {
+			o.Responses = append(o.Responses, newResponse("default", op.Responses.Default))
+		}
+	}
+	return o
+}
+
+func newParameter(p *v3.Parameter) *Parameter {
+	np := &Parameter{
+		Name:        p.Name,
+		In:          p.In,
+		Description: p.Description,
+		Required:    p.Required != nil && *p.Required,
+		Deprecated:  p.Deprecated,
+		Example:     renderValue(p.Example),
+	}
+	if p.Schema != nil {
+		np.Schema = newSchema(p.Schema, "", false, 0)
+		if np.Example == "" {
+			np.Example = renderValue(p.Schema.Schema().Example)
+		}
+	}
+	return np
+}
+
+func newResponse(code string, r *v3.Response) *Response {
+	nr := &Response{Code: code, Description: r.Description, Content: newContent(r.Content)}
+	for h := r.Headers.First(); h != nil; h = h.Next() {
+		p := &Parameter{
+			Name:        h.Key(),
+			In:          "header",
+			Description: h.Value().Description,
+			Required:    h.Value().Required,
+			Deprecated:  h.Value().Deprecated,
+			Example:     renderValue(h.Value().Example),
+		}
+		if h.Value().Schema != nil {
+			p.Schema = newSchema(h.Value().Schema, "", false, 0)
+		}
+		nr.Headers = append(nr.Headers, p)
+	}
+	return nr
+}
+
+func newContent(content *orderedmap.Map[string, *v3.MediaType]) (mts []*MediaType) {
+	for c := content.First(); c != nil; c = c.Next() {
+		mt := &MediaType{Type: c.Key()}
+		if c.Value().Schema != nil {
+			mt.Schema = newSchema(c.Value().Schema, "", false, 0)
+		}
+		for e := c.Value().Examples.First(); e != nil; e = e.Next() {
+			if v := renderValue(e.Value().Value); v != "" {
+				mt.Examples = append(mt.Examples, &Example{Name: e.Key(), Summary: e.Value().Summary, Value: v})
+			}
+		}
+		if v := renderValue(c.Value().Example); v != "" {
+			mt.Examples = append(mt.Examples, &Example{Value: v})
+		}
+		if len(mt.Examples) == 0 && c.Value().Schema != nil {
+			if e := schemaExample(c.Value().Schema.Schema()); e != nil {
+				mt.Examples = append(mt.Examples, e)
+			}
+		}
+		mts = append(mts, mt)
+	}
+	return
+}
+
+// schemaExample returns the example declared by s, or one generated from s.
+func schemaExample(s *base.Schema) *Example {
+	if s == nil {
+		return nil
+	}
+	if v := renderValue(s.Example); v != "" {
+		return &Example{Value: v}
+	}
+	b, err := json.MarshalIndent(util.GenerateExample(s), "", "  ")
+	if err != nil {
+		return nil
+	}
+	return &Example{Value: string(b), Generated: true}
+}
+
+func newSchema(sp *base.SchemaProxy, name string, required bool, depth int) *Schema {
+	n := &Schema{Name: name, Required: required}
+	if ref := sp.GetReference(); sp.IsReference() && strings.HasPrefix(ref, schemaPrefix) && !strings.Contains(ref[len(schemaPrefix):], "/") {
+		n.Ref = ref[len(schemaPrefix):]
+		return n
+	}
+	s := sp.Schema()
+	if s == nil {
+		return n
+	}
+
+	n.Type = util.SchemaType(s)
+	n.Format, n.Title, n.Description = s.Format, s.Title, s.Description
+	n.Nullable = s.Nullable != nil && *s.Nullable
+	for _, t := range s.Type {
+		n.Nullable = n.Nullable || t == "null"
+	}
+	n.ReadOnly = s.ReadOnly != nil && *s.ReadOnly
+	n.WriteOnly = s.WriteOnly != nil && *s.WriteOnly
+	n.Deprecated = s.Deprecated != nil && *s.Deprecated
+	for _, e := range s.Enum {
+		n.Enum = append(n.Enum, renderScalar(e))
+	}
+	if s.Const != nil {
+		n.Enum = append(n.Enum, renderScalar(s.Const))
+	}
+	n.Default = renderScalar(s.Default)
+	n.Constraints = constraints(s)
+	if depth >= maxDepth {
+		return n
+	}
+
+	for p := s.Properties.First(); p != nil; p = p.Next() {
+		req := false
+		for _, r := range s.Required {
+			req = req || r == p.Key()
+		}
+		n.Properties = append(n.Properties, newSchema(p.Value(), p.Key(), req, depth+1))
+	}
+	if s.Items != nil && s.Items.IsA() && s.Items.A != nil {
+		n.Items = newSchema(s.Items.A, "", false, depth+1)
+	}
+	if s.AdditionalProperties != nil && s.AdditionalProperties.IsA() && s.AdditionalProperties.A != nil {
+		n.AdditionalProperties = newSchema(s.AdditionalProperties.A, "", false, depth+1)
+	}
+	for _, c := range []struct {
+		name    string
+		schemas []*base.SchemaProxy
+	}{{"allOf", s.AllOf}, {"oneOf", s.OneOf}, {"anyOf", s.AnyOf}} {
+		if len(c.schemas) == 0 {
+			continue
+		}
+		n.Composition = c.name
+		for _, v := range c.schemas {
+			n.Variants = append(n.Variants, newSchema(v, "", false, depth+1))
+		}
+		break
+	}
+	return n
+}
+
+func constraints(s *base.Schema) (cs []string) {
+	number := func(name string, v *float64) {
+		if v != nil {
+			cs = append(cs, name+": "+strconv.FormatFloat(*v, 'f', -1, 64))
+		}
+	}
+	integer := func(name string, v *int64) {
+		if v != nil {
+			cs = append(cs, name+": "+strconv.FormatInt(*v, 10))
+		}
+	}
+	exclusive := func(name string, limit *float64, v *base.DynamicValue[bool, float64]) {
+		switch {
+		case v == nil:
+			number(name, limit)
+		case v.IsA() && v.A:
+			number("exclusive"+strings.ToUpper(name[:1])+name[1:], limit)
+		case v.IsB():
+			number("exclusive"+strings.ToUpper(name[:1])+name[1:], &v.B)
+		default:
+			number(name, limit)
+		}
+	}
+	exclusive("minimum", s.Minimum, s.ExclusiveMinimum)
+	exclusive("maximum", s.Maximum, s.ExclusiveMaximum)
+	number("multipleOf", s.MultipleOf)
+	integer("minLength", s.MinLength)
+	integer("maxLength", s.MaxLength)
+	if s.Pattern != "" {
+		cs = append(cs, "pattern: "+s.Pattern)
+	}
+	integer("minItems", s.MinItems)
+	integer("maxItems", s.MaxItems)
+	if s.UniqueItems != nil && *s.UniqueItems {
+		cs = append(cs, "uniqueItems: true")
+	}
+	integer("minProperties", s.MinProperties)
+	integer("maxProperties", s.MaxProperties)
+	return
+}
+
+// renderScalar renders n as written in the spec when it is a scalar, as JSON otherwise.
+func renderScalar(n *yaml.Node) string {
+	if n != nil && n.Kind == yaml.ScalarNode {
+		return n.Value
+	}
+	return renderValue(n)
+}
+
+// renderValue renders n as indented JSON, or returns an empty string when n can not be rendered.
+func renderValue(n *yaml.Node) string {
+	if n == nil {
+		return ""
+	}
+	v, err := util.DecodeNode(n)
+	if err != nil {
+		return ""
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+var nonSlug = regexp.MustCompile("[^a-z0-9]+")
+
+// slugs hands out names usable in file names and anchors.
+type slugs map[string]struct{}
+
+// unique returns the slug of name, suffixed by a number when it is already used.
+func (s slugs) unique(name string) string {
+	slug := strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if slug == "" {
+		slug = "x"
+	}
+	candidate := slug
+	for i := 2; ; i++ {
+		if _, ok := s[candidate]; !ok {
+			s[candidate] = struct{}{}
+			return candidate
+		}
+		candidate = slug + "-" + strconv.Itoa(i)
+	}
+}
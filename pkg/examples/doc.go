@@ -0,0 +1,4 @@
+// Package examples generates example values for the media types and parameters of an OpenAPI 3 spec that
+// declare a schema but no example, and can write them back into the bundled spec under `components/examples`.
+// It also validates the examples a spec declares against their schema, reporting the ones that drifted.
+package examples
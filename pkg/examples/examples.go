@@ -0,0 +1,222 @@
+package examples
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/libopenapi"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"github.com/telkomindonesia/openapi-utils/internal/util"
+	"github.com/telkomindonesia/openapi-utils/pkg/bundler"
+	"gopkg.in/yaml.v3"
+)
+
+// Example is a value generated from the schema of a media type or a parameter declaring no example.
+type Example struct {
+	// Pointer is the JSON pointer of the media type or the parameter inside the bundled spec.
+	Pointer string `json:"pointer"`
+	// Name is the key of the example under `components/examples` once written back into the spec.
+	Name  string `json:"name"`
+	Value any    `json:"value"`
+}
+
+// Generate returns an example for every media type and parameter lacking one in the spec at the given path,
+// see GenerateDocument. The spec is bundled by bundler.BundleDocumentAt.
+func Generate(ctx context.Context, specPath string, opts ...Option) ([]Example, error) {
+	doc, err := bundle(ctx, specPath, opts)
+	if err != nil {
+		return nil, err
+	}
+	return GenerateDocument(doc)
+}
+
+// GenerateDocument returns an example for every media type and parameter of doc lacking one. The references
+// of doc are expected to be local, such as the ones of a bundled spec.
+func GenerateDocument(doc libopenapi.Document) ([]Example, error) {
+	docv3, err := util.BuildV3Model(doc)
+	if err != nil {
+		return nil, fmt.Errorf("fail to build v3 model: %w", err)
+	}
+
+	g := generator{
+		root:  doc.GetSpecInfo().RootNode,
+		seen:  map[string]struct{}{},
+		names: map[string]struct{}{},
+	}
+	if c := docv3.Model.Components; c != nil {
+		for m := c.Examples.First(); m != nil; m = m.Next() {
+			g.names[m.Key()] = struct{}{}
+		}
+		for m := c.Parameters.First(); m != nil; m = m.Next() {
+			g.parameter(m.Value(), util.Pascal(m.Key()))
+		}
+		for m := c.RequestBodies.First(); m != nil; m = m.Next() {
+			g.content(m.Value().Content, util.Pascal(m.Key())+"Request")
+		}
+		for m := c.Responses.First(); m != nil; m = m.Next() {
+			g.content(m.Value().Content, util.Pascal(m.Key())+"Response")
+		}
+	}
+	if docv3.Model.Paths == nil {
+		return g.examples, nil
+	}
+	for m := docv3.Model.Paths.PathItems.First(); m != nil; m = m.Next() {
+		path, pathItem := m.Key(), m.Value()
+		for _, p := range pathItem.Parameters {
+			g.parameter(p, util.Pascal(p.Name))
+		}
+		for _, method := range util.Methods {
+			op := util.GetOperation(pathItem, method)
+			if op == nil {
+				continue
+			}
+			name := util.Pascal(op.OperationId)
+			if name == "" {
+				name = util.Pascal(method + " " + path)
+			}
+			for _, p := range op.Parameters {
+				g.parameter(p, name+util.Pascal(p.Name))
+			}
+			if op.RequestBody != nil {
+				g.content(op.RequestBody.Content, name+"Request")
+			}
+			if op.Responses == nil {
+				continue
+			}
+			for r := op.Responses.Codes.First(); r != nil; r = r.Next() {
+				g.content(r.Value().Content, name+util.Pascal(r.Key())+"Response")
+			}
+			if op.Responses.Default != nil {
+				g.content(op.Responses.Default.Content, name+"DefaultResponse")
+			}
+		}
+	}
+	return g.examples, nil
+}
+
+// generator collects the examples of a document, once for every media type or parameter shared through references.
+type generator struct {
+	root     *yaml.Node
+	seen     map[string]struct{}
+	names    map[string]struct{}
+	examples []Example
+}
+
+func (g *generator) parameter(p *v3.Parameter, name string) {
+	if p == nil || p.Schema == nil || p.Example != nil || orderedmap.Len(p.Examples) > 0 {
+		return
+	}
+	g.add(p.GoLow().RootNode, name+"Parameter", util.GenerateExample(p.Schema.Schema()))
+}
+
+func (g *generator) content(content *orderedmap.Map[string, *v3.MediaType], name string) {
+	for m := content.First(); m != nil; m = m.Next() {
+		mt := m.Value()
+		if mt.Schema == nil || mt.Example != nil || orderedmap.Len(mt.Examples) > 0 {
+			continue
+		}
+		n := name
+		if orderedmap.Len(content) > 1 {
+			n += util.Pascal(strings.TrimPrefix(m.Key(), "application/"))
+		}
+		g.add(mt.GoLow().RootNode, n, util.GenerateExample(mt.Schema.Schema()))
+	}
+}
+
+func (g *generator) add(node *yaml.Node, name string, v any) {
+	pointer := strings.TrimPrefix(util.PointerTo(g.root, node), "#")
+	if pointer == "" {
+		return
+	}
+	if _, ok := g.seen[pointer]; ok {
+		return
+	}
+	g.seen[pointer] = struct{}{}
+
+	unique := name
+	for i := 2; ; i++ {
+		if _, ok := g.names[unique]; !ok {
+			break
+		}
+		unique = name + strconv.Itoa(i)
+	}
+	g.names[unique] = struct{}{}
+	g.examples = append(g.examples, Example{Pointer: pointer, Name: unique, Value: v})
+}
+
+// Write returns the spec at the given path, bundled by bundler.BundleDocumentAt, with an example generated
+// for every media type and parameter lacking one, see WriteDocument.
+func Write(ctx context.Context, specPath string, opts ...Option) ([]byte, error) {
+	doc, err := bundle(ctx, specPath, opts)
+	if err != nil {
+		return nil, err
+	}
+	return WriteDocument(doc)
+}
+
+// WriteDocument renders doc with an example generated for every media type and parameter lacking one.
+// The examples are added under `components/examples` and referenced from the `examples` of the media
+// types and parameters under the `generated` key. doc itself is left unchanged.
+func WriteDocument(doc libopenapi.Document) ([]byte, error) {
+	examples, err := GenerateDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var root yaml.Node
+	if err = yaml.Unmarshal(*doc.GetSpecInfo().SpecBytes, &root); err != nil {
+		return nil, fmt.Errorf("fail to parse spec: %w", err)
+	}
+	if len(examples) > 0 {
+		componentExamples := mappingEntry(mappingEntry(util.LookupPointer(&root, ""), "components"), "examples")
+		componentExamples.Style &^= yaml.FlowStyle
+		for _, e := range examples {
+			n := util.LookupPointer(&root, e.Pointer)
+			if n == nil {
+				return nil, fmt.Errorf("fail to locate '%s'", e.Pointer)
+			}
+			ref := &yaml.Node{Kind: yaml.MappingNode}
+			ref.Content = []*yaml.Node{scalar("$ref"), scalar("#/components/examples/" + e.Name)}
+			refs := mappingEntry(n, "examples")
+			refs.Style &^= yaml.FlowStyle
+			refs.Content = append(refs.Content, scalar("generated"), ref)
+
+			value := &yaml.Node{}
+			if err = value.Encode(e.Value); err != nil {
+				return nil, fmt.Errorf("fail to encode example '%s': %w", e.Name, err)
+			}
+			example := &yaml.Node{Kind: yaml.MappingNode}
+			example.Content = []*yaml.Node{scalar("summary"), scalar("Generated from the schema"), scalar("value"), value}
+			componentExamples.Content = append(componentExamples.Content, scalar(e.Name), example)
+		}
+	}
+
+	b, err := yaml.Marshal(&root)
+	if err != nil {
+		return nil, fmt.Errorf("fail to render spec: %w", err)
+	}
+	return b, nil
+}
+
+func bundle(ctx context.Context, specPath string, opts []Option) (libopenapi.Document, error) {
+	return bundler.BundleDocumentAt(ctx, specPath, newOptions(opts).bundlerOptions()...)
+}
+
+// mappingEntry returns the mapping held by the key of m, adding an empty one when m does not have the key.
+func mappingEntry(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	v := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	m.Content = append(m.Content, scalar(key), v)
+	return v
+}
+
+func scalar(v string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+}
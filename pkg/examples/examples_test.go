@@ -0,0 +1,130 @@
+package examples
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"github.com/stretchr/testify/require"
+	"github.com/telkomindonesia/openapi-utils/internal/testutil"
+	"github.com/telkomindonesia/openapi-utils/internal/util"
+	"github.com/telkomindonesia/openapi-utils/pkg/reference"
+)
+
+func TestGenerate(t *testing.T) {
+	examples, err := Generate(context.Background(), "./testdata/spec.yml")
+	require.NoError(t, err)
+
+	b, err := json.Marshal(examples)
+	require.NoError(t, err)
+	require.JSONEq(t, `[
+		{
+			"pointer": "/components/responses/Error/content/application~1json",
+			"name": "ErrorResponse",
+			"value": {"message": "string"}
+		},
+		{
+			"pointer": "/paths/~1orders~1{order-id}/parameters/0",
+			"name": "OrderIdParameter",
+			"value": "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+		},
+		{
+			"pointer": "/paths/~1orders~1{order-id}/get/responses/200/content/application~1json",
+			"name": "GetOrder200Response",
+			"value": {
+				"id": "3fa85f64-5717-4562-b3fc-2c963f66afa6",
+				"code": "ORD-0000",
+				"quantity": 1,
+				"price": 0.5,
+				"status": "pending",
+				"createdAt": "2024-01-01T00:00:00Z",
+				"note": "stringstri",
+				"customer": {"name": "Jane Doe", "phoneNumber": "+6281234567890", "email": "jane.doe@example.com"},
+				"payment": {"number": "0000000000000000"}
+			}
+		}
+	]`, string(b))
+}
+
+func TestGenerateValid(t *testing.T) {
+	b, err := Write(context.Background(), "./testdata/spec.yml")
+	require.NoError(t, err)
+	doc, err := libopenapi.NewDocument(b)
+	require.NoError(t, err)
+	docv3, errs := doc.BuildV3Model()
+	require.Empty(t, errs)
+
+	for m := docv3.Model.Components.Schemas.First(); m != nil; m = m.Next() {
+		schema := m.Value().Schema()
+		require.NoError(t, util.ValidateValue(schema, util.GenerateExample(schema)), m.Key())
+	}
+}
+
+func TestWrite(t *testing.T) {
+	b, err := Write(context.Background(), "./testdata/spec.yml")
+	require.NoError(t, err)
+	doc, err := libopenapi.NewDocument(b)
+	require.NoError(t, err)
+
+	examples, err := GenerateDocument(doc)
+	require.NoError(t, err)
+	require.Empty(t, examples, "every media type and parameter should have an example")
+	require.NoError(t, ValidateDocument(doc), "generated examples should match their schema")
+
+	docv3, errs := doc.BuildV3Model()
+	require.Empty(t, errs)
+	var names []string
+	for m := docv3.Model.Components.Examples.First(); m != nil; m = m.Next() {
+		names = append(names, m.Key())
+	}
+	require.Equal(t, []string{"ErrorResponse", "OrderIdParameter", "GetOrder200Response"}, names)
+
+	pathItem, _ := docv3.Model.Paths.PathItems.Get("/orders/{order-id}")
+	resp, _ := pathItem.Get.Responses.Codes.Get("200")
+	generated, ok := resp.Content.GetOrZero("application/json").Examples.Get("generated")
+	require.True(t, ok)
+	v, err := util.DecodeNode(generated.Value)
+	require.NoError(t, err)
+	require.Equal(t, "ORD-0000", v.(map[string]any)["code"])
+
+	put := pathItem.Put.RequestBody.Content.GetOrZero("application/json")
+	require.NotNil(t, put.Example, "existing examples should be kept")
+	require.Zero(t, orderedmap.Len(put.Examples))
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate(context.Background(), "./testdata/spec.yml"))
+
+	expected := []string{
+		"testdata/invalid/components.yml:10:20: at '/components/schemas/Order/properties/id/example': invalid example: value is not a valid uuid",
+		"testdata/invalid/components.yml:25:13: at '/components/responses/Error/content/application~1json/example': invalid example: /message: expected string, got number",
+		"testdata/invalid/spec.yml:15:20: at '/paths/~1orders/get/parameters/0/example': invalid example: expected integer, got string",
+		"testdata/invalid/spec.yml:32:21: at '/paths/~1orders/get/responses/200/content/application~1json/examples/drifted/value': invalid example: /0: missing required property 'id'",
+		"testdata/invalid/spec.yml:32:21: at '/paths/~1orders/get/responses/200/content/application~1json/examples/drifted/value': invalid example: /0/quantity: expected integer, got string",
+	}
+	messages := func(err error) (m []string) {
+		var verrs reference.Errors
+		require.ErrorAs(t, err, &verrs)
+		for _, e := range verrs {
+			m = append(m, e.Error())
+		}
+		return
+	}
+	require.Equal(t, expected, messages(Validate(context.Background(), "./testdata/invalid/spec.yml")))
+
+	// the files referenced by the spec only exist inside LocalFS
+	dir := filepath.ToSlash(t.TempDir())
+	b, err := os.ReadFile("./testdata/invalid/spec.yml")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(dir+"/spec.yml", b, 0o644))
+	err = Validate(context.Background(), dir+"/spec.yml", WithBasePath(dir), WithLocalFS(testutil.MapFS(t, "./testdata/invalid", "")))
+	for i := range expected {
+		expected[i] = strings.Replace(expected[i], "testdata/invalid", dir, 1)
+	}
+	require.Equal(t, expected, messages(err))
+}
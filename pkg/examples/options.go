@@ -0,0 +1,55 @@
+package examples
+
+import (
+	"io/fs"
+	"log/slog"
+
+	"github.com/telkomindonesia/openapi-utils/pkg/bundler"
+)
+
+// Options holds the settings of the example generation.
+type Options struct {
+	// Logger receives warnings emitted while bundling the spec.
+	Logger *slog.Logger
+	// BasePath is the directory relative references are resolved from, see bundler.Options.BasePath.
+	BasePath string
+	// LocalFS is the filesystem relative references are read from, see bundler.Options.LocalFS.
+	LocalFS fs.FS
+}
+
+type Option func(*Options)
+
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+func WithBasePath(basePath string) Option {
+	return func(o *Options) {
+		o.BasePath = basePath
+	}
+}
+
+func WithLocalFS(fsys fs.FS) Option {
+	return func(o *Options) {
+		o.LocalFS = fsys
+	}
+}
+
+func newOptions(opts []Option) Options {
+	o := Options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// bundlerOptions returns the options the spec is bundled with.
+func (o Options) bundlerOptions() []bundler.Option {
+	bopts := []bundler.Option{bundler.WithLogger(o.Logger), bundler.WithLocalFS(o.LocalFS)}
+	if o.BasePath != "" {
+		bopts = append(bopts, bundler.WithBasePath(o.BasePath))
+	}
+	return bopts
+}
@@ -0,0 +1,249 @@
+package examples
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"github.com/telkomindonesia/openapi-utils/internal/util"
+	"github.com/telkomindonesia/openapi-utils/pkg/bundler"
+	"github.com/telkomindonesia/openapi-utils/pkg/reference"
+	"gopkg.in/yaml.v3"
+)
+
+// errInvalidExample wraps the mismatches between an example and its schema.
+var errInvalidExample = errors.New("invalid example")
+
+// Validate validates every example of the spec at the given path against its schema, see ValidateDocument.
+// The spec is bundled by bundler.BundleDocumentAt with the source of its components annotated, so that the
+// reference.Errors locate the examples inside the files they are written in.
+func Validate(ctx context.Context, specPath string, opts ...Option) error {
+	bopts := append(newOptions(opts).bundlerOptions(), bundler.WithSourceAnnotations(true))
+	doc, err := bundler.BundleDocumentAt(ctx, specPath, bopts...)
+	if err != nil {
+		return err
+	}
+
+	err = ValidateDocument(doc)
+	var verrs reference.Errors
+	if errors.As(err, &verrs) {
+		traceSources(verrs, doc.GetSpecInfo().RootNode, specPath, bundler.ReadFunc(specPath, bopts...))
+	}
+	return err
+}
+
+// ValidateDocument validates the `example` and `examples` of every media type, parameter, header and schema
+// of doc, including the ones of its components, against their schema. It returns reference.Errors holding
+// every violation found, located inside doc. The references of doc are expected to be local.
+func ValidateDocument(doc libopenapi.Document) error {
+	docv3, err := util.BuildV3Model(doc)
+	if err != nil {
+		return fmt.Errorf("fail to build v3 model: %w", err)
+	}
+
+	v := validator{root: doc.GetSpecInfo().RootNode, seen: map[string]struct{}{}}
+	if c := docv3.Model.Components; c != nil {
+		for m := c.Schemas.First(); m != nil; m = m.Next() {
+			v.schema(m.Value().Schema())
+		}
+		for m := c.Parameters.First(); m != nil; m = m.Next() {
+			v.parameter(m.Value())
+		}
+		for m := c.Headers.First(); m != nil; m = m.Next() {
+			v.header(m.Value())
+		}
+		for m := c.RequestBodies.First(); m != nil; m = m.Next() {
+			v.content(m.Value().Content)
+		}
+		for m := c.Responses.First(); m != nil; m = m.Next() {
+			v.response(m.Value())
+		}
+	}
+	if docv3.Model.Paths != nil {
+		for m := docv3.Model.Paths.PathItems.First(); m != nil; m = m.Next() {
+			for _, p := range m.Value().Parameters {
+				v.parameter(p)
+			}
+			for _, method := range util.Methods {
+				op := util.GetOperation(m.Value(), method)
+				if op == nil {
+					continue
+				}
+				for _, p := range op.Parameters {
+					v.parameter(p)
+				}
+				if op.RequestBody != nil {
+					v.content(op.RequestBody.Content)
+				}
+				if op.Responses == nil {
+					continue
+				}
+				for r := op.Responses.Codes.First(); r != nil; r = r.Next() {
+					v.response(r.Value())
+				}
+				v.response(op.Responses.Default)
+			}
+		}
+	}
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// validator collects the violations of the examples of a document, reporting each of them once even when
+// the example is reached through several references.
+type validator struct {
+	root *yaml.Node
+	seen map[string]struct{}
+	errs reference.Errors
+}
+
+func (v *validator) response(r *v3.Response) {
+	if r == nil {
+		return
+	}
+	for m := r.Headers.First(); m != nil; m = m.Next() {
+		v.header(m.Value())
+	}
+	v.content(r.Content)
+}
+
+func (v *validator) parameter(p *v3.Parameter) {
+	if p == nil {
+		return
+	}
+	v.examples(p.Schema, p.Example, p.Examples)
+	v.content(p.Content)
+}
+
+func (v *validator) header(h *v3.Header) {
+	if h == nil {
+		return
+	}
+	v.examples(h.Schema, h.Example, h.Examples)
+	v.content(h.Content)
+}
+
+func (v *validator) content(content *orderedmap.Map[string, *v3.MediaType]) {
+	for m := content.First(); m != nil; m = m.Next() {
+		v.examples(m.Value().Schema, m.Value().Example, m.Value().Examples)
+	}
+}
+
+// examples validates the example and the examples declared next to a schema, then the ones of the schema itself
+// when it is declared inline.
+func (v *validator) examples(sp *base.SchemaProxy, example *yaml.Node, examples *orderedmap.Map[string, *base.Example]) {
+	if sp == nil {
+		return
+	}
+	s := sp.Schema()
+	v.validate(s, example)
+	for m := examples.First(); m != nil; m = m.Next() {
+		v.validate(s, m.Value().Value)
+	}
+	if !sp.IsReference() {
+		v.schema(s)
+	}
+}
+
+// schema validates the examples of s and of its inline subschemas, the referenced ones being validated
+// as components.
+func (v *validator) schema(s *base.Schema) {
+	if s == nil {
+		return
+	}
+	v.validate(s, s.Example)
+	for _, e := range s.Examples {
+		v.validate(s, e)
+	}
+
+	subschemas := append(append(append(append([]*base.SchemaProxy{}, s.AllOf...), s.OneOf...), s.AnyOf...), s.PrefixItems...)
+	for m := s.Properties.First(); m != nil; m = m.Next() {
+		subschemas = append(subschemas, m.Value())
+	}
+	subschemas = append(subschemas, s.Not)
+	if s.Items != nil && s.Items.IsA() {
+		subschemas = append(subschemas, s.Items.A)
+	}
+	if s.AdditionalProperties != nil && s.AdditionalProperties.IsA() {
+		subschemas = append(subschemas, s.AdditionalProperties.A)
+	}
+	for _, sp := range subschemas {
+		if sp != nil && !sp.IsReference() {
+			v.schema(sp.Schema())
+		}
+	}
+}
+
+func (v *validator) validate(s *base.Schema, example *yaml.Node) {
+	if s == nil || example == nil {
+		return
+	}
+	pointer := strings.TrimPrefix(util.PointerTo(v.root, example), "#")
+	report := func(err error) {
+		key := pointer + "\x00" + strconv.Itoa(example.Line) + ":" + strconv.Itoa(example.Column) + "\x00" + err.Error()
+		if _, ok := v.seen[key]; ok {
+			return
+		}
+		v.seen[key] = struct{}{}
+		v.errs = append(v.errs, &reference.Error{
+			Line: example.Line, Column: example.Column, Pointer: pointer, Err: fmt.Errorf("%w: %w", errInvalidExample, err),
+		})
+	}
+
+	value, err := util.DecodeNode(example)
+	if err != nil {
+		report(err)
+		return
+	}
+	var serrs util.SchemaErrors
+	if errors.As(util.ValidateValue(s, value), &serrs) {
+		for _, e := range serrs {
+			report(e)
+		}
+	}
+}
+
+// traceSources points the errors at the files the examples are written in, specPath being the path of the
+// bundled spec and read the function its files are read with. The examples located in neither are left
+// pointing at the bundled spec.
+func traceSources(errs reference.Errors, root *yaml.Node, specPath string, read func(name string) ([]byte, error)) {
+	files := map[string]*yaml.Node{}
+	lookup := func(file string, pointer string) *yaml.Node {
+		n, ok := files[file]
+		if !ok {
+			n = &yaml.Node{}
+			if b, err := read(filepath.ToSlash(file)); err != nil || yaml.Unmarshal(b, n) != nil {
+				n = nil
+			}
+			files[file] = n
+		}
+		if n == nil {
+			return nil
+		}
+		return util.LookupPointer(n, pointer)
+	}
+
+	for _, e := range errs {
+		file, pointer := filepath.Clean(specPath), e.Pointer
+		if src, translated, ok := bundler.LookupSource(root, e.Pointer); ok {
+			file = filepath.Join(filepath.Dir(specPath), filepath.FromSlash(src.File))
+			pointer = strings.TrimPrefix(translated, "#")
+		}
+		// the position is the one inside the bundled spec, which means nothing in the source file
+		e.Line, e.Column = 0, 0
+		if n := lookup(file, pointer); n != nil {
+			e.File, e.Pointer, e.Line, e.Column = file, pointer, n.Line, n.Column
+		}
+	}
+	errs.Sort()
+}
@@ -0,0 +1,296 @@
+// Package gitfs exposes the tree of a local Git repository at a given revision as an [fs.FS],
+// so specs can be bundled or compiled as they existed at that revision without checking it out.
+package gitfs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FS is the tree of a Git repository at a given revision.
+// Only the listing of the tree is read upfront, the content of a file is read from the
+// Git object store when it is opened. FS is safe for concurrent use.
+type FS struct {
+	ctx     context.Context
+	dir     string
+	entries map[string]*entry
+	// children holds the sorted names of the entries of every directory, keyed by its path.
+	children map[string][]string
+
+	mu    sync.Mutex
+	batch *batch
+}
+
+type entry struct {
+	name string
+	mode fs.FileMode
+	oid  string
+	size int64
+}
+
+// New returns the tree of the repository containing dir at the given revision,
+// which may be anything accepted by `git rev-parse` such as a commit, a tag or a branch.
+// The returned FS holds a `git cat-file` process once a file is opened and should be closed when no longer needed.
+func New(ctx context.Context, dir string, rev string) (*FS, error) {
+	b, err := git(ctx, dir, "ls-tree", "-r", "-t", "-l", "-z", "--full-tree", rev+"^{tree}")
+	if err != nil {
+		return nil, fmt.Errorf("fail to read git revision '%s': %w", rev, err)
+	}
+
+	f := &FS{
+		ctx:      ctx,
+		dir:      dir,
+		entries:  map[string]*entry{".": {name: ".", mode: fs.ModeDir | 0o555}},
+		children: map[string][]string{},
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(string(b), "\x00"), "\x00") {
+		if line == "" {
+			continue
+		}
+		e, name, err := parseEntry(line)
+		if err != nil {
+			return nil, fmt.Errorf("fail to read git tree of revision '%s': %w", rev, err)
+		}
+		if e == nil {
+			continue
+		}
+		f.entries[name] = e
+		f.children[path.Dir(name)] = append(f.children[path.Dir(name)], e.name)
+	}
+	for _, names := range f.children {
+		sort.Strings(names)
+	}
+	return f, nil
+}
+
+// parseEntry parses a line of `git ls-tree -l` output.
+// Entries that are neither files nor directories, such as submodules, are returned as nil.
+func parseEntry(line string) (e *entry, name string, err error) {
+	meta, name, ok := strings.Cut(line, "\t")
+	fields := strings.Fields(meta)
+	if !ok || len(fields) != 4 {
+		return nil, "", fmt.Errorf("unexpected entry '%s'", line)
+	}
+
+	e = &entry{name: path.Base(name), oid: fields[2]}
+	switch fields[1] {
+	case "tree":
+		e.mode = fs.ModeDir | 0o555
+	case "blob":
+		e.mode = 0o444
+		if e.size, err = strconv.ParseInt(fields[3], 10, 64); err != nil {
+			return nil, "", fmt.Errorf("unexpected size of entry '%s': %w", line, err)
+		}
+	default:
+		return nil, name, nil
+	}
+	return e, name, nil
+}
+
+// Open implements [fs.FS].
+func (f *FS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	e, ok := f.entries[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if e.mode.IsDir() {
+		return &dir{info: e, entries: f.dirEntries(name)}, nil
+	}
+
+	b, err := f.read(e.oid)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &file{info: e, Reader: bytes.NewReader(b)}, nil
+}
+
+// ReadDir implements [fs.ReadDirFS].
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	if e, ok := f.entries[name]; !ok || !e.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return f.dirEntries(name), nil
+}
+
+func (f *FS) dirEntries(name string) []fs.DirEntry {
+	entries := make([]fs.DirEntry, 0, len(f.children[name]))
+	for _, child := range f.children[name] {
+		entries = append(entries, fs.FileInfoToDirEntry(f.entries[path.Join(name, child)]))
+	}
+	return entries
+}
+
+// Close stops the `git cat-file` process reading the content of files, if any.
+func (f *FS) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.batch == nil {
+		return nil
+	}
+	err := f.batch.close()
+	f.batch = nil
+	return err
+}
+
+// read returns the content of the blob with the given object id.
+func (f *FS) read(oid string) (b []byte, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.batch == nil {
+		if f.batch, err = startBatch(f.ctx, f.dir); err != nil {
+			return nil, fmt.Errorf("fail to start git cat-file: %w", err)
+		}
+	}
+	b, err = f.batch.read(oid)
+	if err != nil {
+		// the process can not be trusted to be in sync with its output anymore
+		_ = f.batch.close()
+		f.batch = nil
+		return nil, fmt.Errorf("fail to read git object '%s': %w", oid, err)
+	}
+	return b, nil
+}
+
+// batch is a `git cat-file --batch` process, answering the requests for an object id with its content.
+type batch struct {
+	cmd *exec.Cmd
+	in  io.WriteCloser
+	out *bufio.Reader
+}
+
+func startBatch(ctx context.Context, dir string) (*batch, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", dir, "cat-file", "--batch")
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &batch{cmd: cmd, in: in, out: bufio.NewReader(out)}, nil
+}
+
+func (c *batch) read(oid string) ([]byte, error) {
+	if _, err := io.WriteString(c.in, oid+"\n"); err != nil {
+		return nil, err
+	}
+	header, err := c.out.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(header)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("unexpected response '%s'", strings.TrimSpace(header))
+	}
+	size, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected size in response '%s': %w", strings.TrimSpace(header), err)
+	}
+
+	// the content is followed by a newline
+	b := make([]byte, size+1)
+	if _, err = io.ReadFull(c.out, b); err != nil {
+		return nil, err
+	}
+	return b[:size], nil
+}
+
+func (c *batch) close() error {
+	_ = c.in.Close()
+	return c.cmd.Wait()
+}
+
+type file struct {
+	info *entry
+	*bytes.Reader
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *file) Close() error               { return nil }
+
+type dir struct {
+	info    *entry
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *dir) Close() error               { return nil }
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+// ReadDir implements [fs.ReadDirFile].
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.offset += n
+	return rest[:n], nil
+}
+
+func (e *entry) Name() string       { return e.name }
+func (e *entry) Size() int64        { return e.size }
+func (e *entry) Mode() fs.FileMode  { return e.mode }
+func (e *entry) ModTime() time.Time { return time.Time{} }
+func (e *entry) IsDir() bool        { return e.mode.IsDir() }
+func (e *entry) Sys() any           { return nil }
+
+// Locate returns the top level directory of the repository containing the file at the given path
+// and the slash-separated path of the file relative to it, as expected by [New] and [fs.FS].
+func Locate(ctx context.Context, file string) (repo string, name string, err error) {
+	dir := filepath.Dir(file)
+	b, err := git(ctx, dir, "rev-parse", "--show-toplevel", "--show-prefix")
+	if err != nil {
+		return "", "", fmt.Errorf("fail to locate git repository of '%s': %w", file, err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	if len(lines) < 2 {
+		lines = append(lines, "")
+	}
+	return lines[0], path.Join(lines[1], filepath.Base(file)), nil
+}
+
+func git(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Stderr = &stderr
+	b, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return b, nil
+}
@@ -0,0 +1,55 @@
+package gitfs
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+	repo := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@localhost"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	write := func(name string, content string) {
+		p := filepath.Join(repo, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o644))
+	}
+
+	run("init", "-q")
+	write("specs/spec.yml", "v1")
+	write("specs/schemas/schema.yml", "type: object")
+	run("add", ".")
+	run("commit", "-q", "-m", "v1")
+	run("tag", "v1")
+	write("specs/spec.yml", "v2")
+	run("commit", "-q", "-am", "v2")
+	write("specs/spec.yml", "v3")
+
+	r, name, err := Locate(ctx, filepath.Join(repo, "specs", "spec.yml"))
+	require.NoError(t, err)
+	require.Equal(t, "specs/spec.yml", name)
+
+	for rev, expected := range map[string]string{"v1": "v1", "HEAD": "v2"} {
+		fsys, err := New(ctx, r, rev)
+		require.NoError(t, err)
+		b, err := fs.ReadFile(fsys, name)
+		require.NoError(t, err)
+		require.Equal(t, expected, string(b), "content at revision %s", rev)
+		require.NoError(t, fstest.TestFS(fsys, name, "specs/schemas/schema.yml"))
+		require.NoError(t, fsys.Close())
+	}
+
+	_, err = New(ctx, r, "not-exist")
+	require.Error(t, err)
+}
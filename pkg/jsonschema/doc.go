@@ -0,0 +1,9 @@
+// Package jsonschema exports the schema components of an OpenAPI 3 spec as standalone JSON Schema documents.
+//
+// Every exported document holds the component together with the components it references, directly or not,
+// under `$defs`, or `definitions` for draft-07. The OpenAPI 3.0 specifics are translated: `nullable` adds
+// `null` to the types, `example` is moved into `examples`, the boolean `exclusiveMinimum` and
+// `exclusiveMaximum` become numeric, and the `discriminator` of a `oneOf` or `anyOf` constrains the
+// discriminating property of each alternative. Extensions and keywords without a JSON Schema
+// counterpart, such as `xml`, are dropped.
+package jsonschema
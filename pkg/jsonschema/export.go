@@ -0,0 +1,369 @@
+package jsonschema
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/telkomindonesia/openapi-utils/internal/util"
+	"github.com/telkomindonesia/openapi-utils/pkg/bundler"
+	"gopkg.in/yaml.v3"
+)
+
+// Draft identifies a JSON Schema draft by the URI of its meta-schema.
+type Draft string
+
+const (
+	Draft202012 Draft = "https://json-schema.org/draft/2020-12/schema"
+	Draft07     Draft = "http://json-schema.org/draft-07/schema#"
+)
+
+// ParseDraft returns the Draft named by s, either `2020-12` or `draft-07`, or its meta-schema URI.
+func ParseDraft(s string) (Draft, error) {
+	switch s {
+	case "2020-12", "draft-2020-12", string(Draft202012):
+		return Draft202012, nil
+	case "07", "draft-07", "7", string(Draft07):
+		return Draft07, nil
+	}
+	return "", fmt.Errorf("unsupported JSON Schema draft '%s'", s)
+}
+
+// definitions returns the keyword holding the subschemas referenced by the document.
+func (d Draft) definitions() string {
+	if d == Draft07 {
+		return "definitions"
+	}
+	return "$defs"
+}
+
+// File is a JSON Schema document exported from a schema component.
+type File struct {
+	// Name is the name of the component.
+	Name string
+	// Schema is the JSON Schema document, indented with two spaces.
+	Schema []byte
+}
+
+// Export exports the schema components of the spec at the given path, see ExportDocument. The spec is
+// bundled by bundler.BundleDocumentAt.
+func Export(ctx context.Context, specPath string, opts ...Option) ([]File, error) {
+	o := Options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	// the source is preserved for the recursive and the unreferenced schemas to be kept
+	doc, err := bundler.BundleDocumentAt(ctx, specPath, bundler.WithPreserveSource(true), bundler.WithLogger(o.Logger))
+	if err != nil {
+		return nil, err
+	}
+	return export(doc.GetSpecInfo().RootNode, o)
+}
+
+// ExportDocument exports the schema components of doc, or the ones selected by Options.Schemas, in their
+// declaration order. The references of doc are expected to be local.
+func ExportDocument(doc libopenapi.Document, opts ...Option) ([]File, error) {
+	o := Options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return export(doc.GetSpecInfo().RootNode, o)
+}
+
+func export(root *yaml.Node, o Options) (files []File, err error) {
+	if o.Draft == "" {
+		o.Draft = Draft202012
+	}
+	schemas := util.LookupPointer(root, "/components/schemas")
+	if schemas == nil || schemas.Kind != yaml.MappingNode {
+		schemas = &yaml.Node{Kind: yaml.MappingNode}
+	}
+
+	names := o.Schemas
+	if len(names) == 0 {
+		for i := 0; i+1 < len(schemas.Content); i += 2 {
+			names = append(names, schemas.Content[i].Value)
+		}
+	}
+	for _, name := range names {
+		if lookup(schemas, name) == nil {
+			return nil, fmt.Errorf("fail to export schema '%s': no such component", name)
+		}
+		e := &exporter{schemas: schemas, draft: o.Draft, name: name, seen: map[string]struct{}{name: {}}}
+		b, err := util.MarshalJSON(e.document())
+		if err != nil {
+			return nil, fmt.Errorf("fail to render schema '%s': %w", name, err)
+		}
+		files = append(files, File{Name: name, Schema: b})
+	}
+	return
+}
+
+// exporter converts a schema component and the ones it references into a JSON Schema document.
+type exporter struct {
+	schemas *yaml.Node
+	draft   Draft
+	name    string
+	// defs lists the referenced components in the order they are found.
+	defs []string
+	seen map[string]struct{}
+}
+
+func (e *exporter) document() *yaml.Node {
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	doc.Content = append(doc.Content, str("$schema"), str(string(e.draft)))
+	s := e.schema(lookup(e.schemas, e.name))
+	if s.Kind == yaml.MappingNode {
+		doc.Content = append(doc.Content, s.Content...)
+	} else {
+		doc.Content = append(doc.Content, str("allOf"), seq(s))
+	}
+
+	// converting a definition may find more of them
+	defs := &yaml.Node{Kind: yaml.MappingNode}
+	for i := 0; i < len(e.defs); i++ {
+		defs.Content = append(defs.Content, str(e.defs[i]), e.schema(lookup(e.schemas, e.defs[i])))
+	}
+	if len(defs.Content) > 0 {
+		doc.Content = append(doc.Content, str(e.draft.definitions()), defs)
+	}
+	return doc
+}
+
+// ref rewrites a reference to a schema component into a reference to its definition, or to the root of the
+// document for the exported component.
+func (e *exporter) ref(ref string) string {
+	rest, ok := strings.CutPrefix(ref, "#/components/schemas/")
+	if !ok {
+		return ref
+	}
+	name, pointer, _ := strings.Cut(rest, "/")
+	if pointer != "" {
+		pointer = "/" + pointer
+	}
+	name = strings.ReplaceAll(strings.ReplaceAll(name, "~1", "/"), "~0", "~")
+	if name == e.name {
+		return "#" + pointer
+	}
+	if _, ok := e.seen[name]; !ok && lookup(e.schemas, name) != nil {
+		e.seen[name] = struct{}{}
+		e.defs = append(e.defs, name)
+	}
+	return "#/" + e.draft.definitions() + "/" + util.EscapePointer(name) + pointer
+}
+
+// schema returns a JSON Schema copy of the OpenAPI schema n.
+func (e *exporter) schema(n *yaml.Node) *yaml.Node {
+	if n == nil {
+		return &yaml.Node{Kind: yaml.MappingNode}
+	}
+	if n.Kind == yaml.AliasNode {
+		return e.schema(n.Alias)
+	}
+	if n.Kind != yaml.MappingNode {
+		return clone(n)
+	}
+
+	s := &yaml.Node{Kind: yaml.MappingNode}
+	var nullable bool
+	var example, discriminator, exclusiveMinimum, exclusiveMaximum *yaml.Node
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		switch key := k.Value; {
+		case strings.HasPrefix(key, "x-"), key == "xml", key == "externalDocs":
+			continue
+		case key == "nullable":
+			nullable = v.Value == "true"
+			continue
+		case key == "example":
+			example = v
+			continue
+		case key == "discriminator":
+			discriminator = v
+			continue
+		case key == "exclusiveMinimum" && v.ShortTag() == "!!bool":
+			exclusiveMinimum = v
+			continue
+		case key == "exclusiveMaximum" && v.ShortTag() == "!!bool":
+			exclusiveMaximum = v
+			continue
+		case key == "$ref" && v.Kind == yaml.ScalarNode:
+			v = str(e.ref(v.Value))
+		case subschemaMaps[key] && v.Kind == yaml.MappingNode:
+			m := &yaml.Node{Kind: yaml.MappingNode}
+			for j := 0; j+1 < len(v.Content); j += 2 {
+				m.Content = append(m.Content, clone(v.Content[j]), e.schema(v.Content[j+1]))
+			}
+			v = m
+		case subschemaLists[key] && v.Kind == yaml.SequenceNode:
+			l := &yaml.Node{Kind: yaml.SequenceNode}
+			for _, c := range v.Content {
+				l.Content = append(l.Content, e.schema(c))
+			}
+			v = l
+		case subschemas[key]:
+			v = e.schema(v)
+		default:
+			v = clone(v)
+		}
+		s.Content = append(s.Content, clone(k), v)
+	}
+
+	exclusive(s, "minimum", "exclusiveMinimum", exclusiveMinimum)
+	exclusive(s, "maximum", "exclusiveMaximum", exclusiveMaximum)
+	if example != nil {
+		if examples := lookup(s, "examples"); examples != nil && examples.Kind == yaml.SequenceNode {
+			examples.Content = append(examples.Content, clone(example))
+		} else {
+			s.Content = append(s.Content, str("examples"), seq(clone(example)))
+		}
+	}
+	if discriminator != nil {
+		e.discriminate(s, discriminator)
+	}
+	if nullable {
+		s = nullify(s)
+	}
+	return s
+}
+
+// subschemaMaps, subschemaLists and subschemas list the keywords holding subschemas by name, in a list, or directly.
+var (
+	subschemaMaps  = map[string]bool{"properties": true, "patternProperties": true, "$defs": true, "definitions": true, "dependentSchemas": true}
+	subschemaLists = map[string]bool{"allOf": true, "anyOf": true, "oneOf": true, "prefixItems": true, "items": true}
+	subschemas     = map[string]bool{
+		"items": true, "additionalProperties": true, "not": true, "contains": true, "propertyNames": true, "if": true,
+		"then": true, "else": true, "unevaluatedProperties": true, "unevaluatedItems": true, "additionalItems": true,
+	}
+)
+
+// exclusive converts the boolean form of exclusiveMinimum or exclusiveMaximum into the numeric one.
+func exclusive(s *yaml.Node, limit string, keyword string, flag *yaml.Node) {
+	if flag == nil || flag.Value != "true" {
+		return
+	}
+	for i := 0; i+1 < len(s.Content); i += 2 {
+		if s.Content[i].Value == limit {
+			s.Content[i] = str(keyword)
+			return
+		}
+	}
+}
+
+// discriminate constrains the discriminating property of every alternative of s referencing a component to
+// the values the discriminator maps to it, which default to the name of the component.
+func (e *exporter) discriminate(s *yaml.Node, discriminator *yaml.Node) {
+	property := lookup(discriminator, "propertyName")
+	if property == nil {
+		return
+	}
+	values := map[string][]string{}
+	if mapping := lookup(discriminator, "mapping"); mapping != nil && mapping.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			ref := mapping.Content[i+1].Value
+			if !strings.HasPrefix(ref, "#") {
+				ref = "#/components/schemas/" + ref
+			}
+			ref = e.ref(ref)
+			values[ref] = append(values[ref], mapping.Content[i].Value)
+		}
+	}
+
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		alternatives := lookup(s, keyword)
+		if alternatives == nil || alternatives.Kind != yaml.SequenceNode {
+			continue
+		}
+		for i, alt := range alternatives.Content {
+			ref := lookup(alt, "$ref")
+			if ref == nil {
+				continue
+			}
+			v := values[ref.Value]
+			if len(v) == 0 {
+				v = []string{ref.Value[strings.LastIndex(ref.Value, "/")+1:]}
+			}
+			constraint := &yaml.Node{Kind: yaml.MappingNode}
+			switch len(v) {
+			case 1:
+				constraint.Content = append(constraint.Content, str("const"), str(v[0]))
+			default:
+				enum := &yaml.Node{Kind: yaml.SequenceNode}
+				for _, value := range v {
+					enum.Content = append(enum.Content, str(value))
+				}
+				constraint.Content = append(constraint.Content, str("enum"), enum)
+			}
+			alternatives.Content[i] = mapping(
+				"allOf", seq(alt, mapping(
+					"properties", mapping(property.Value, constraint),
+					"required", seq(str(property.Value)),
+				)),
+			)
+		}
+	}
+}
+
+// nullify makes s accept null.
+func nullify(s *yaml.Node) *yaml.Node {
+	t := lookup(s, "type")
+	switch {
+	case t != nil && t.Kind == yaml.ScalarNode:
+		*t = *seq(str(t.Value), str("null"))
+	case t != nil && t.Kind == yaml.SequenceNode:
+		for _, c := range t.Content {
+			if c.Value == "null" {
+				return s
+			}
+		}
+		t.Content = append(t.Content, str("null"))
+	default:
+		return mapping("anyOf", seq(s, mapping("type", str("null"))))
+	}
+	if enum := lookup(s, "enum"); enum != nil && enum.Kind == yaml.SequenceNode {
+		enum.Content = append(enum.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"})
+	}
+	return s
+}
+
+func lookup(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func clone(n *yaml.Node) *yaml.Node {
+	if n.Kind == yaml.AliasNode {
+		return clone(n.Alias)
+	}
+	c := *n
+	c.Content = make([]*yaml.Node, 0, len(n.Content))
+	for _, child := range n.Content {
+		c.Content = append(c.Content, clone(child))
+	}
+	return &c
+}
+
+func str(s string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}
+}
+
+func seq(items ...*yaml.Node) *yaml.Node {
+	return &yaml.Node{Kind: yaml.SequenceNode, Content: items}
+}
+
+// mapping returns a mapping of the given keys and values, which alternate.
+func mapping(kv ...any) *yaml.Node {
+	m := &yaml.Node{Kind: yaml.MappingNode}
+	for i := 0; i+1 < len(kv); i += 2 {
+		m.Content = append(m.Content, str(kv[i].(string)), kv[i+1].(*yaml.Node))
+	}
+	return m
+}
@@ -0,0 +1,63 @@
+package jsonschema
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	files, err := Export(context.Background(), "./testdata/spec.yml", WithSchemas("Pet"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "Pet", files[0].Name)
+	require.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"oneOf": [
+			{"allOf": [{"$ref": "#/$defs/Cat"}, {"properties": {"kind": {"enum": ["kitten", "cat"]}}, "required": ["kind"]}]},
+			{"allOf": [{"$ref": "#/$defs/Dog"}, {"properties": {"kind": {"const": "Dog"}}, "required": ["kind"]}]}
+		],
+		"$defs": {
+			"Cat": {
+				"type": "object",
+				"required": ["kind"],
+				"properties": {
+					"kind": {"type": "string"},
+					"lives": {"type": "integer", "exclusiveMinimum": 0, "maximum": 9, "examples": [7]},
+					"friend": {"$ref": "#"}
+				}
+			},
+			"Dog": {
+				"type": "object",
+				"properties": {
+					"kind": {"type": "string"},
+					"size": {"type": ["string", "null"], "enum": ["small", "large", null]},
+					"owner": {"$ref": "#/$defs/Owner"}
+				}
+			},
+			"Owner": {"anyOf": [{"allOf": [{"$ref": "#/$defs/Name"}]}, {"type": "null"}]},
+			"Name": {"type": "string", "pattern": "^[A-Z]"}
+		}
+	}`, string(files[0].Schema))
+}
+
+func TestExportDraft07(t *testing.T) {
+	files, err := Export(context.Background(), "./testdata/spec.yml", WithDraft(Draft07))
+	require.NoError(t, err)
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	require.Equal(t, []string{"Pet", "Cat", "Dog", "Unused", "Owner", "Name"}, names)
+	require.JSONEq(t, `{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"anyOf": [{"allOf": [{"$ref": "#/definitions/Name"}]}, {"type": "null"}],
+		"definitions": {
+			"Name": {"type": "string", "pattern": "^[A-Z]"}
+		}
+	}`, string(files[4].Schema))
+
+	_, err = Export(context.Background(), "./testdata/spec.yml", WithSchemas("Missing"))
+	require.ErrorContains(t, err, "fail to export schema 'Missing': no such component")
+}
@@ -0,0 +1,35 @@
+package jsonschema
+
+import "log/slog"
+
+// Options holds the settings of the export.
+type Options struct {
+	// Logger receives warnings emitted while bundling the spec.
+	Logger *slog.Logger
+	// Draft is the JSON Schema draft the documents are written in, Draft202012 when empty.
+	Draft Draft
+	// Schemas selects the names of the components to export, every schema component is exported when empty.
+	Schemas []string
+}
+
+type Option func(*Options)
+
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+// WithDraft sets the JSON Schema draft of the exported documents, see Options.Draft.
+func WithDraft(draft Draft) Option {
+	return func(o *Options) {
+		o.Draft = draft
+	}
+}
+
+// WithSchemas selects the components to export, see Options.Schemas.
+func WithSchemas(names ...string) Option {
+	return func(o *Options) {
+		o.Schemas = append(o.Schemas, names...)
+	}
+}
@@ -0,0 +1,6 @@
+// Package mock serves every operation of an OpenAPI 3 spec over HTTP, answering with the examples the spec
+// declares or with values generated from the response schemas, after validating the requests against the spec.
+//
+// The response status is the lowest declared 2xx one unless the request asks for another one through the
+// `Prefer: code=<status>` header. A named example may be selected with `Prefer: example=<name>`.
+package mock
@@ -0,0 +1,169 @@
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pb33f/libopenapi"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"github.com/telkomindonesia/openapi-utils/internal/util"
+	"github.com/telkomindonesia/openapi-utils/pkg/bundler"
+)
+
+// Server is an http.Handler serving every operation of a spec.
+type Server struct {
+	opts   Options
+	routes []route
+	// basePaths holds the paths of the server URLs of the spec, requests may be prefixed by any of them.
+	basePaths []string
+}
+
+// New returns a Server serving the spec at the given path, bundled by bundler.BundleDocumentAt.
+func New(ctx context.Context, specPath string, opts ...Option) (*Server, error) {
+	doc, err := bundler.BundleDocumentAt(ctx, specPath, bundler.WithLogger(newOptions(opts).Logger))
+	if err != nil {
+		return nil, err
+	}
+	return NewFromDocument(doc, opts...)
+}
+
+// NewFromDocument returns a Server serving doc, whose references are expected to be local,
+// such as the one of a bundled spec or of a compiled proxy spec.
+func NewFromDocument(doc libopenapi.Document, opts ...Option) (*Server, error) {
+	docv3, errs := doc.BuildV3Model()
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("fail to build v3 model: %w", err)
+	}
+
+	s := &Server{opts: newOptions(opts)}
+	if docv3.Model.Paths != nil {
+		for m := range orderedmap.Iterate(context.Background(), docv3.Model.Paths.PathItems) {
+			s.routes = append(s.routes, newRoute(m.Key(), m.Value()))
+		}
+	}
+	// paths without parameters take precedence over the templated ones they also match
+	sort.SliceStable(s.routes, func(i, j int) bool { return len(s.routes[i].params) < len(s.routes[j].params) })
+
+	for _, server := range docv3.Model.Servers {
+		u, err := url.Parse(server.URL)
+		if err != nil {
+			continue
+		}
+		if p := strings.TrimSuffix(u.Path, "/"); p != "" {
+			s.basePaths = append(s.basePaths, p)
+		}
+	}
+	return s, nil
+}
+
+func newOptions(opts []Option) (o Options) {
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt, params, ok := s.match(r.URL.EscapedPath())
+	if !ok {
+		writeProblem(w, http.StatusNotFound, fmt.Sprintf("no operation is declared for path '%s'", r.URL.Path), nil)
+		return
+	}
+	ops := util.GetOperationsMap(rt.pathItem)
+	op, ok := ops[strings.ToLower(r.Method)]
+	if !ok {
+		methods := make([]string, 0, len(ops))
+		for m := range ops {
+			methods = append(methods, strings.ToUpper(m))
+		}
+		sort.Strings(methods)
+		w.Header().Set("Allow", strings.Join(methods, ", "))
+		writeProblem(w, http.StatusMethodNotAllowed, fmt.Sprintf("method %s is not declared for path '%s'", r.Method, rt.template), nil)
+		return
+	}
+
+	if !s.opts.SkipValidation {
+		if status, errs := validateRequest(r, rt.pathItem, op, params); len(errs) > 0 {
+			writeProblem(w, status, "invalid request", errs)
+			return
+		}
+	}
+	respond(w, r, op)
+}
+
+// route matches the request paths of a path item.
+type route struct {
+	template string
+	pattern  *regexp.Regexp
+	params   []string
+	pathItem *v3.PathItem
+}
+
+var templateParam = regexp.MustCompile(`\{([^}/]+)\}`)
+
+func newRoute(template string, pathItem *v3.PathItem) route {
+	rt := route{template: template, pathItem: pathItem}
+	b := strings.Builder{}
+	b.WriteString("^")
+	last := 0
+	for _, m := range templateParam.FindAllStringSubmatchIndex(template, -1) {
+		b.WriteString(regexp.QuoteMeta(template[last:m[0]]))
+		b.WriteString("([^/]+)")
+		rt.params = append(rt.params, template[m[2]:m[3]])
+		last = m[1]
+	}
+	b.WriteString(regexp.QuoteMeta(template[last:]))
+	b.WriteString("$")
+	rt.pattern = regexp.MustCompile(b.String())
+	return rt
+}
+
+// match returns the route matching the escaped request path, with or without any of the base paths, together with
+// the unescaped values of its path parameters.
+func (s *Server) match(path string) (rt route, params map[string]string, ok bool) {
+	candidates := []string{path}
+	for _, base := range s.basePaths {
+		if rest, ok := strings.CutPrefix(path, base); ok && strings.HasPrefix(rest, "/") {
+			candidates = append(candidates, rest)
+		}
+	}
+	for _, p := range candidates {
+		for _, rt := range s.routes {
+			m := rt.pattern.FindStringSubmatch(p)
+			if m == nil {
+				continue
+			}
+			params = make(map[string]string, len(rt.params))
+			for i, name := range rt.params {
+				v, err := url.PathUnescape(m[i+1])
+				if err != nil {
+					v = m[i+1]
+				}
+				params[name] = v
+			}
+			return rt, params, true
+		}
+	}
+	return rt, nil, false
+}
+
+// problem is the body of the responses to requests the server refuses, following RFC 9457.
+type problem struct {
+	Title  string   `json:"title"`
+	Status int      `json:"status"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+func writeProblem(w http.ResponseWriter, status int, title string, errs []string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(problem{Title: title, Status: status, Errors: errs})
+}
@@ -0,0 +1,177 @@
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/telkomindonesia/openapi-utils/pkg/proxy"
+)
+
+func TestServer(t *testing.T) {
+	s, err := New(context.Background(), "./testdata/spec.yml")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		header  map[string]string
+		body    string
+		status  int
+		expect  string
+		errors  []string
+		headers map[string]string
+	}{
+		{
+			name:   "example selected by name",
+			method: http.MethodGet, path: "/profiles/3fa85f64-5717-4562-b3fc-2c963f66afa6",
+			header: map[string]string{"Prefer": "example=john"},
+			status: http.StatusOK,
+			expect: `{"id": "0c7e6f5d-4b3a-4d2c-9e1f-8a7b6c5d4e3f", "name": "John"}`,
+		},
+		{
+			name:   "first example",
+			method: http.MethodGet, path: "/profiles/3fa85f64-5717-4562-b3fc-2c963f66afa6",
+			status: http.StatusOK,
+			expect: `{"id": "9b2a4c3e-1f0d-4c8a-8f5e-2d7b6a1c0e9f", "name": "Jane"}`,
+		},
+		{
+			name:   "path without parameter takes precedence",
+			method: http.MethodGet, path: "/profiles/me",
+			status: http.StatusOK,
+			expect: `{"id": "5d1c2b3a-4e5f-4a6b-8c7d-9e0f1a2b3c4d", "name": "Me"}`,
+		},
+		{
+			name:   "status selected by prefer header",
+			method: http.MethodGet, path: "/api/profiles/3fa85f64-5717-4562-b3fc-2c963f66afa6",
+			header: map[string]string{"Prefer": "code=404"},
+			status: http.StatusNotFound,
+			expect: `{"message": "not found"}`,
+		},
+		{
+			name:   "generated from schema",
+			method: http.MethodGet, path: "/profiles",
+			header: map[string]string{"Accept": "application/json"},
+			status: http.StatusOK,
+			expect: `[{"id": "3fa85f64-5717-4562-b3fc-2c963f66afa6", "name": "Jane Doe", "email": "user@example.com", "tags": ["string"]}]`,
+		},
+		{
+			name:   "valid request",
+			method: http.MethodPost, path: "/profiles",
+			header:  map[string]string{"Content-Type": "application/json"},
+			body:    `{"id": "3fa85f64-5717-4562-b3fc-2c963f66afa6", "name": "Jane"}`,
+			status:  http.StatusCreated,
+			headers: map[string]string{"Location": "/profiles/3fa85f64-5717-4562-b3fc-2c963f66afa6"},
+		},
+		{
+			name:   "invalid body",
+			method: http.MethodPost, path: "/profiles",
+			header: map[string]string{"Content-Type": "application/json"},
+			body:   `{"id": "not-a-uuid", "tags": [1]}`,
+			status: http.StatusBadRequest,
+			errors: []string{
+				"request body: missing required property 'name'",
+				"request body/id: value is not a valid uuid",
+				"request body/tags/0: expected string, got number",
+			},
+		},
+		{
+			name:   "missing body",
+			method: http.MethodPost, path: "/profiles",
+			status: http.StatusBadRequest,
+			errors: []string{"missing required request body"},
+		},
+		{
+			name:   "unsupported content type",
+			method: http.MethodPost, path: "/profiles",
+			header: map[string]string{"Content-Type": "text/plain"},
+			body:   "jane",
+			status: http.StatusUnsupportedMediaType,
+			errors: []string{"content type 'text/plain' is not accepted"},
+		},
+		{
+			name:   "invalid parameters",
+			method: http.MethodGet, path: "/profiles?limit=1000",
+			status: http.StatusBadRequest,
+			errors: []string{"query parameter 'limit': expected a value <= 100, got 1000"},
+		},
+		{
+			name:   "invalid path parameter",
+			method: http.MethodGet, path: "/profiles/jane",
+			status: http.StatusBadRequest,
+			errors: []string{"path parameter 'profile-id': value is not a valid uuid"},
+		},
+		{
+			name:   "unknown path",
+			method: http.MethodGet, path: "/tenants",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "undeclared method",
+			method: http.MethodDelete, path: "/profiles",
+			status:  http.StatusMethodNotAllowed,
+			headers: map[string]string{"Allow": "GET, POST"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, r)
+
+			require.Equal(t, tt.status, w.Code, w.Body.String())
+			if tt.expect != "" {
+				require.JSONEq(t, tt.expect, w.Body.String())
+			}
+			if tt.errors != nil {
+				var p problem
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+				require.Equal(t, tt.errors, p.Errors)
+			}
+			for k, v := range tt.headers {
+				require.Equal(t, v, w.Header().Get(k))
+			}
+		})
+	}
+}
+
+func TestServerSkipValidation(t *testing.T) {
+	s, err := New(context.Background(), "./testdata/spec.yml", WithSkipValidation(true))
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodPost, "/profiles", strings.NewReader(`{"id": 1}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	require.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestServerProxyDocument(t *testing.T) {
+	_, doc, err := proxy.Compile(context.Background(), "../proxy/testdata/spec-proxy.yml")
+	require.NoError(t, err)
+	s, err := NewFromDocument(doc)
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "/profiles/3fa85f64-5717-4562-b3fc-2c963f66afa6", nil)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), "the response should be generated from the prefixed upstream schema")
+	require.NotEmpty(t, body)
+
+	r = httptest.NewRequest(http.MethodPut, "/profiles/3fa85f64-5717-4562-b3fc-2c963f66afa6", strings.NewReader(`{"name": 1}`))
+	r.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code, "the request should be validated against the upstream request body")
+	require.Contains(t, w.Body.String(), "name")
+}
@@ -0,0 +1,26 @@
+package mock
+
+import "log/slog"
+
+// Options holds the settings of a mock Server.
+type Options struct {
+	// Logger receives warnings emitted while bundling the spec.
+	Logger *slog.Logger
+	// SkipValidation answers every request without validating its parameters and body against the spec.
+	SkipValidation bool
+}
+
+type Option func(*Options)
+
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+// WithSkipValidation disables the validation of requests, see Options.SkipValidation.
+func WithSkipValidation(skip bool) Option {
+	return func(o *Options) {
+		o.SkipValidation = skip
+	}
+}
@@ -0,0 +1,171 @@
+package mock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"github.com/telkomindonesia/openapi-utils/internal/util"
+)
+
+// validateRequest validates the parameters and the body of r against the operation declared by the path item,
+// returning every violation found together with the status the request should be refused with.
+func validateRequest(r *http.Request, pathItem *v3.PathItem, op *v3.Operation, pathParams map[string]string) (status int, errs []string) {
+	for _, p := range util.CopyParameters(op.Parameters, pathItem.Parameters...) {
+		errs = append(errs, validateParameter(r, p, pathParams)...)
+	}
+	status = http.StatusBadRequest
+	if op.RequestBody != nil {
+		berrs, unsupported := validateBody(r, op.RequestBody)
+		if unsupported {
+			status = http.StatusUnsupportedMediaType
+		}
+		errs = append(errs, berrs...)
+	}
+	return
+}
+
+func validateParameter(r *http.Request, p *v3.Parameter, pathParams map[string]string) (errs []string) {
+	var values []string
+	switch p.In {
+	case "path":
+		if v, ok := pathParams[p.Name]; ok {
+			values = []string{v}
+		}
+	case "query":
+		values = r.URL.Query()[p.Name]
+	case "header":
+		values = r.Header.Values(p.Name)
+	case "cookie":
+		if c, err := r.Cookie(p.Name); err == nil {
+			values = []string{c.Value}
+		}
+	}
+
+	if len(values) == 0 {
+		if p.Required != nil && *p.Required {
+			errs = append(errs, fmt.Sprintf("missing required %s parameter '%s'", p.In, p.Name))
+		}
+		return
+	}
+	if p.Schema == nil {
+		return
+	}
+	schema := p.Schema.Schema()
+	if schema == nil || util.SchemaType(schema) == "object" {
+		return
+	}
+
+	var serrs util.SchemaErrors
+	if err := util.ValidateValue(schema, parameterValue(schema, values)); errors.As(err, &serrs) {
+		for _, e := range serrs {
+			errs = append(errs, fmt.Sprintf("%s parameter '%s'%s: %s", p.In, p.Name, e.Pointer, e.Message))
+		}
+	}
+	return
+}
+
+// parameterValue converts the raw values of a parameter into the type of its schema. Values that can not be
+// converted are kept as strings for the validation to report them.
+func parameterValue(schema *base.Schema, values []string) any {
+	if util.SchemaType(schema) != "array" {
+		return scalarValue(schema, values[0])
+	}
+
+	if len(values) == 1 {
+		values = strings.Split(values[0], ",")
+	}
+	var items *base.Schema
+	if schema.Items != nil && schema.Items.IsA() && schema.Items.A != nil {
+		items = schema.Items.A.Schema()
+	}
+	a := make([]any, 0, len(values))
+	for _, v := range values {
+		if items == nil {
+			a = append(a, v)
+			continue
+		}
+		a = append(a, scalarValue(items, v))
+	}
+	return a
+}
+
+func scalarValue(schema *base.Schema, v string) any {
+	switch util.SchemaType(schema) {
+	case "integer", "number":
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+// validateBody validates the body of r against the request body, unsupported tells whether its content type is not accepted.
+func validateBody(r *http.Request, rb *v3.RequestBody) (errs []string, unsupported bool) {
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return []string{fmt.Sprintf("fail to read request body: %s", err)}, false
+	}
+	if len(b) == 0 {
+		if rb.Required != nil && *rb.Required {
+			return []string{"missing required request body"}, false
+		}
+		return nil, false
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	mt, ok := lookupMediaType(rb.Content, contentType)
+	if !ok {
+		return []string{fmt.Sprintf("content type '%s' is not accepted", contentType)}, true
+	}
+	if !isJSON(contentType) || mt.Schema == nil {
+		return nil, false
+	}
+
+	var v any
+	if err = json.Unmarshal(b, &v); err != nil {
+		return []string{fmt.Sprintf("invalid JSON request body: %s", err)}, false
+	}
+	var serrs util.SchemaErrors
+	if err = util.ValidateValue(mt.Schema.Schema(), v); errors.As(err, &serrs) {
+		for _, e := range serrs {
+			errs = append(errs, fmt.Sprintf("request body%s: %s", e.Pointer, e.Message))
+		}
+	}
+	return errs, false
+}
+
+// lookupMediaType returns the media type of content matching the given content type, directly or through a wildcard.
+func lookupMediaType(content *orderedmap.Map[string, *v3.MediaType], contentType string) (*v3.MediaType, bool) {
+	if content == nil {
+		return nil, false
+	}
+	t, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	major, _, _ := strings.Cut(t, "/")
+	for _, key := range []string{t, major + "/*", "*/*"} {
+		if mt, ok := content.Get(key); ok {
+			return mt, true
+		}
+	}
+	return nil, false
+}
+
+func isJSON(contentType string) bool {
+	t, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (t == "application/json" || strings.HasSuffix(t, "+json"))
+}
@@ -0,0 +1,177 @@
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"github.com/telkomindonesia/openapi-utils/internal/util"
+)
+
+// respond writes the response of the operation selected by the `Prefer` and `Accept` headers of r.
+func respond(w http.ResponseWriter, r *http.Request, op *v3.Operation) {
+	status, resp := selectResponse(op.Responses, preference(r, "code"))
+	if resp == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	for h := range orderedmap.Iterate(context.Background(), resp.Headers) {
+		header := h.Value()
+		var v any
+		switch {
+		case header.Example != nil:
+			v, _ = util.DecodeNode(header.Example)
+		case header.Schema != nil:
+			v = util.GenerateExample(header.Schema.Schema())
+		}
+		if v != nil {
+			w.Header().Set(h.Key(), fmt.Sprint(v))
+		}
+	}
+
+	contentType, mt := selectMediaType(resp.Content, r.Header.Get("Accept"))
+	if mt == nil {
+		w.WriteHeader(status)
+		return
+	}
+	body := example(mt, preference(r, "example"))
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	if s, ok := body.(string); ok && !isJSON(contentType) {
+		_, _ = io.WriteString(w, s)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// selectResponse returns the response declared for the preferred status, or for the lowest 2xx status when
+// there is no preference. The `default` response is used for the statuses not declared otherwise.
+func selectResponse(responses *v3.Responses, preferred string) (status int, resp *v3.Response) {
+	if responses == nil {
+		return http.StatusNoContent, nil
+	}
+
+	if code, err := strconv.Atoi(preferred); err == nil && responses.Codes != nil {
+		if resp, ok := responses.Codes.Get(preferred); ok {
+			return code, resp
+		}
+		if resp, ok := responses.Codes.Get(preferred[:1] + "XX"); ok {
+			return code, resp
+		}
+		if responses.Default != nil {
+			return code, responses.Default
+		}
+	}
+
+	var first *v3.Response
+	firstStatus := 0
+	for m := range orderedmap.Iterate(context.Background(), responses.Codes) {
+		code := statusOf(m.Key())
+		if first == nil {
+			first, firstStatus = m.Value(), code
+		}
+		if code >= 200 && code < 300 && (resp == nil || code < status) {
+			status, resp = code, m.Value()
+		}
+	}
+	switch {
+	case resp != nil:
+		return status, resp
+	case responses.Default != nil:
+		return http.StatusOK, responses.Default
+	}
+	return firstStatus, first
+}
+
+// statusOf returns the status of a response key, ranges such as `2XX` being given their lowest status.
+func statusOf(key string) int {
+	if code, err := strconv.Atoi(key); err == nil {
+		return code
+	}
+	if code, err := strconv.Atoi(key[:1]); err == nil && strings.EqualFold(key[1:], "XX") {
+		return code * 100
+	}
+	return http.StatusOK
+}
+
+// selectMediaType returns the first media type of content accepted by the `Accept` header,
+// preferring JSON when every media type is accepted.
+func selectMediaType(content *orderedmap.Map[string, *v3.MediaType], accept string) (string, *v3.MediaType) {
+	if content == nil || content.Len() == 0 {
+		return "", nil
+	}
+	for _, a := range strings.Split(accept, ",") {
+		t, _, err := mime.ParseMediaType(strings.TrimSpace(a))
+		if err != nil || t == "*/*" {
+			continue
+		}
+		for m := content.First(); m != nil; m = m.Next() {
+			if mediaTypeMatches(t, m.Key()) {
+				return m.Key(), m.Value()
+			}
+		}
+	}
+	if mt, ok := content.Get("application/json"); ok {
+		return "application/json", mt
+	}
+	m := content.First()
+	return m.Key(), m.Value()
+}
+
+func mediaTypeMatches(accepted string, declared string) bool {
+	if accepted == declared {
+		return true
+	}
+	major, minor, _ := strings.Cut(accepted, "/")
+	return minor == "*" && strings.HasPrefix(declared, major+"/")
+}
+
+// example returns the example of the media type named name, its first example when there is no such example,
+// or a value generated from its schema when it has no example.
+func example(mt *v3.MediaType, name string) any {
+	if mt.Examples != nil && name != "" {
+		if e, ok := mt.Examples.Get(name); ok && e.Value != nil {
+			if v, err := util.DecodeNode(e.Value); err == nil {
+				return v
+			}
+		}
+	}
+	if mt.Example != nil {
+		if v, err := util.DecodeNode(mt.Example); err == nil {
+			return v
+		}
+	}
+	for m := mt.Examples.First(); m != nil; m = m.Next() {
+		if m.Value().Value == nil {
+			continue
+		}
+		if v, err := util.DecodeNode(m.Value().Value); err == nil {
+			return v
+		}
+	}
+	if mt.Schema != nil {
+		return util.GenerateExample(mt.Schema.Schema())
+	}
+	return nil
+}
+
+// preference returns the value of the given preference of the `Prefer` headers of r, e.g. `code` in `Prefer: code=404`.
+func preference(r *http.Request, name string) string {
+	for _, h := range r.Header.Values("Prefer") {
+		for _, p := range strings.FieldsFunc(h, func(c rune) bool { return c == ',' || c == ';' }) {
+			k, v, ok := strings.Cut(strings.TrimSpace(p), "=")
+			if ok && strings.EqualFold(k, name) {
+				return strings.Trim(strings.TrimSpace(v), `"`)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,246 @@
+// Package overlay applies OpenAPI Overlay 1.0 documents, which describe a sequence of `update` and `remove`
+// actions targeting the nodes of an OpenAPI document selected by JSONPath expressions.
+package overlay
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
+	"gopkg.in/yaml.v3"
+)
+
+// Overlay is an OpenAPI Overlay document.
+type Overlay struct {
+	// Overlay is the version of the Overlay Specification the document uses, only 1.x is supported.
+	Overlay string `yaml:"overlay"`
+	Info    Info   `yaml:"info"`
+	// Extends is the URI of the document the overlay is meant for. It is informative only.
+	Extends string   `yaml:"extends,omitempty"`
+	Actions []Action `yaml:"actions"`
+}
+
+// Info holds the metadata of an overlay.
+type Info struct {
+	Title   string `yaml:"title"`
+	Version string `yaml:"version"`
+}
+
+// Action is a single change applied to every node selected by its target.
+type Action struct {
+	// Target is the JSONPath expression selecting the nodes to change.
+	Target      string `yaml:"target"`
+	Description string `yaml:"description,omitempty"`
+	// Update is merged into every selected node: objects are merged recursively, with the values of
+	// Update replacing the existing ones, and arrays get Update appended, or concatenated when it is an array.
+	Update yaml.Node `yaml:"update,omitempty"`
+	// Remove removes every selected node from its parent, Update is ignored when it is set.
+	Remove bool `yaml:"remove,omitempty"`
+}
+
+// Load reads and validates the overlay at the given path.
+func Load(path string) (*Overlay, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("fail to read overlay: %w", err)
+	}
+	o, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("fail to parse overlay '%s': %w", path, err)
+	}
+	return o, nil
+}
+
+// Parse parses and validates an overlay document written in YAML or JSON.
+func Parse(b []byte) (*Overlay, error) {
+	o := &Overlay{}
+	if err := yaml.Unmarshal(b, o); err != nil {
+		return nil, fmt.Errorf("fail to decode overlay: %w", err)
+	}
+	return o, o.Validate()
+}
+
+// Validate checks that the overlay has every required field and that every target is a valid JSONPath expression.
+func (o *Overlay) Validate() error {
+	var errs []error
+	switch {
+	case o.Overlay == "":
+		errs = append(errs, errors.New("missing `overlay` version"))
+	case !strings.HasPrefix(o.Overlay, "1."):
+		errs = append(errs, fmt.Errorf("unsupported overlay version '%s'", o.Overlay))
+	}
+	if o.Info.Title == "" {
+		errs = append(errs, errors.New("missing `info.title`"))
+	}
+	if o.Info.Version == "" {
+		errs = append(errs, errors.New("missing `info.version`"))
+	}
+	if len(o.Actions) == 0 {
+		errs = append(errs, errors.New("missing `actions`"))
+	}
+	for i, a := range o.Actions {
+		if a.Target == "" {
+			errs = append(errs, fmt.Errorf("action %d: missing `target`", i))
+			continue
+		}
+		if _, err := yamlpath.NewPath(a.Target); err != nil {
+			errs = append(errs, fmt.Errorf("action %d: invalid target '%s': %w", i, a.Target, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Apply applies the overlay to the given YAML or JSON document and renders the result as YAML.
+func (o *Overlay) Apply(spec []byte) ([]byte, error) {
+	root := &yaml.Node{}
+	if err := yaml.Unmarshal(spec, root); err != nil {
+		return nil, fmt.Errorf("fail to parse spec: %w", err)
+	}
+	if err := o.ApplyNode(root); err != nil {
+		return nil, err
+	}
+	b, err := yaml.Marshal(root)
+	if err != nil {
+		return nil, fmt.Errorf("fail to render spec: %w", err)
+	}
+	return b, nil
+}
+
+// ApplyNode applies every action of the overlay, in order, to the document rooted at root.
+// Targets selecting no node are not an error.
+func (o *Overlay) ApplyNode(root *yaml.Node) error {
+	for i, a := range o.Actions {
+		if err := a.apply(root); err != nil {
+			return fmt.Errorf("fail to apply action %d targeting '%s': %w", i, a.Target, err)
+		}
+	}
+	return nil
+}
+
+func (a Action) apply(root *yaml.Node) error {
+	p, err := yamlpath.NewPath(a.Target)
+	if err != nil {
+		return fmt.Errorf("invalid target: %w", err)
+	}
+	nodes, err := p.Find(root)
+	if err != nil {
+		return fmt.Errorf("fail to evaluate target: %w", err)
+	}
+
+	if a.Remove {
+		targets := make(map[*yaml.Node]struct{}, len(nodes))
+		for _, n := range nodes {
+			targets[n] = struct{}{}
+		}
+		remove(root, targets)
+		return nil
+	}
+	if a.Update.Kind == 0 {
+		return nil
+	}
+	for _, n := range nodes {
+		if err := update(n, &a.Update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func update(target *yaml.Node, value *yaml.Node) error {
+	if value.Kind == yaml.DocumentNode && len(value.Content) > 0 {
+		value = value.Content[0]
+	}
+	switch target.Kind {
+	case yaml.MappingNode:
+		if value.Kind != yaml.MappingNode {
+			return fmt.Errorf("can not merge %s into an object at line %d", kindName(value), target.Line)
+		}
+		merge(target, value)
+	case yaml.SequenceNode:
+		if value.Kind == yaml.SequenceNode {
+			for _, c := range value.Content {
+				target.Content = append(target.Content, copyNode(c))
+			}
+			break
+		}
+		target.Content = append(target.Content, copyNode(value))
+	default:
+		return fmt.Errorf("can not update %s at line %d, only objects and arrays can be updated", kindName(target), target.Line)
+	}
+	return nil
+}
+
+// merge merges the mapping value into the mapping target, recursing into the objects both of them have.
+func merge(target *yaml.Node, value *yaml.Node) {
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		k, v := value.Content[i], value.Content[i+1]
+		j := indexOfKey(target, k.Value)
+		switch {
+		case j < 0:
+			target.Content = append(target.Content, copyNode(k), copyNode(v))
+		case target.Content[j+1].Kind == yaml.MappingNode && v.Kind == yaml.MappingNode:
+			merge(target.Content[j+1], v)
+		default:
+			target.Content[j+1] = copyNode(v)
+		}
+	}
+}
+
+// remove removes every node of targets from its parent inside the tree rooted at n.
+func remove(n *yaml.Node, targets map[*yaml.Node]struct{}) {
+	content := n.Content[:0]
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if _, ok := targets[n.Content[i+1]]; ok {
+				continue
+			}
+			remove(n.Content[i+1], targets)
+			content = append(content, n.Content[i], n.Content[i+1])
+		}
+	default:
+		for _, c := range n.Content {
+			if _, ok := targets[c]; ok && n.Kind == yaml.SequenceNode {
+				continue
+			}
+			remove(c, targets)
+			content = append(content, c)
+		}
+	}
+	n.Content = content
+}
+
+func indexOfKey(m *yaml.Node, key string) int {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// copyNode deep copies n, rendering it in block style like the rest of the document.
+func copyNode(n *yaml.Node) *yaml.Node {
+	c := *n
+	c.Style &^= yaml.FlowStyle
+	c.Content = make([]*yaml.Node, 0, len(n.Content))
+	for _, child := range n.Content {
+		c.Content = append(c.Content, copyNode(child))
+	}
+	return &c
+}
+
+func kindName(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "an object"
+	case yaml.SequenceNode:
+		return "an array"
+	case yaml.AliasNode:
+		return "an alias"
+	default:
+		return "a scalar"
+	}
+}
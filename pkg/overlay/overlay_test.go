@@ -0,0 +1,257 @@
+package overlay
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
+	"gopkg.in/yaml.v3"
+)
+
+const spec = `
+openapi: 3.0.0
+info:
+  title: Profile API
+  version: 1.0.0
+servers:
+  - url: https://localhost:8443
+tags:
+  - name: internal
+paths:
+  /profiles:
+    get:
+      description: list profiles
+      tags: [public]
+      parameters:
+        - name: limit
+          in: query
+    post:
+      description: create profile
+      tags: [internal]
+  /health:
+    get:
+      description: health check
+      x-internal: true
+`
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		actions  string
+		expected string
+		absent   []string
+	}{
+		{
+			name: "update merges objects recursively",
+			actions: `
+- target: $.info
+  update:
+    description: manages profiles
+    version: 2.0.0
+- target: $.paths['/profiles'].get
+  update:
+    summary: list
+    description: list every profile
+`,
+			expected: `
+info: {title: Profile API, version: 2.0.0, description: manages profiles}
+paths:
+  /profiles:
+    get: {description: list every profile, tags: [public], parameters: [{name: limit, in: query}], summary: list}
+`,
+		},
+		{
+			name: "update replaces arrays held by objects",
+			actions: `
+- target: $
+  update:
+    servers:
+      - url: https://profile.example.com
+`,
+			expected: `
+servers: [{url: https://profile.example.com}]
+`,
+		},
+		{
+			name: "update appends to arrays",
+			actions: `
+- target: $.paths.*.get.parameters
+  update:
+    name: offset
+    in: query
+- target: $.tags
+  update:
+    - name: public
+    - name: admin
+`,
+			expected: `
+tags: [{name: internal}, {name: public}, {name: admin}]
+paths:
+  /profiles:
+    get: {parameters: [{name: limit, in: query}, {name: offset, in: query}]}
+`,
+		},
+		{
+			name: "update every node selected by a filter",
+			actions: `
+- target: $.paths.*.*[?(@.description)]
+  update:
+    x-reviewed: true
+`,
+			expected: `
+paths:
+  /profiles:
+    get: {x-reviewed: true}
+    post: {x-reviewed: true}
+  /health:
+    get: {x-reviewed: true}
+`,
+		},
+		{
+			name: "remove object members",
+			actions: `
+- target: $.paths.*.*[?(@.x-internal == true)]
+  remove: true
+- target: $.paths['/profiles'].get.description
+  remove: true
+`,
+			expected: `
+paths:
+  /profiles:
+    get: {tags: [public], parameters: [{name: limit, in: query}]}
+    post: {description: create profile}
+  /health: {}
+`,
+			absent: []string{"$.paths['/health'].get", "$.paths['/profiles'].get.description"},
+		},
+		{
+			name: "remove array items",
+			actions: `
+- target: $.paths.*.*[?(@.tags[0] == 'internal')]
+  remove: true
+- target: $.tags[?(@.name == 'internal')]
+  remove: true
+`,
+			expected: `
+tags: []
+paths:
+  /profiles:
+    get: {description: list profiles}
+`,
+			absent: []string{"$.paths['/profiles'].post"},
+		},
+		{
+			name: "remove ignores update",
+			actions: `
+- target: $.servers
+  remove: true
+  update:
+    - url: https://profile.example.com
+`,
+			absent: []string{"$.servers"},
+		},
+		{
+			name: "target selecting nothing",
+			actions: `
+- target: $.paths['/unknown']
+  update:
+    description: unknown
+- target: $.webhooks
+  remove: true
+`,
+			expected: `
+paths:
+  /profiles:
+    get: {description: list profiles}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := Parse([]byte("overlay: 1.0.0\ninfo: {title: test, version: 1.0.0}\nactions:" + tt.actions))
+			require.NoError(t, err)
+
+			b, err := o.Apply([]byte(spec))
+			require.NoError(t, err)
+
+			var actual, expected map[string]any
+			require.NoError(t, yaml.Unmarshal(b, &actual))
+			require.NoError(t, yaml.Unmarshal([]byte(tt.expected), &expected))
+			requireSubset(t, expected, actual, "$")
+
+			var root yaml.Node
+			require.NoError(t, yaml.Unmarshal(b, &root))
+			for _, target := range tt.absent {
+				p, err := yamlpath.NewPath(target)
+				require.NoError(t, err)
+				nodes, err := p.Find(&root)
+				require.NoError(t, err)
+				require.Empty(t, nodes, "%s should have been removed", target)
+			}
+		})
+	}
+}
+
+func TestApplyError(t *testing.T) {
+	o, err := Parse([]byte(`
+overlay: 1.0.0
+info: {title: test, version: 1.0.0}
+actions:
+  - target: $.info.title
+    update: {description: title}
+`))
+	require.NoError(t, err)
+	_, err = o.Apply([]byte(spec))
+	require.ErrorContains(t, err, "only objects and arrays can be updated")
+
+	o, err = Parse([]byte(`
+overlay: 1.0.0
+info: {title: test, version: 1.0.0}
+actions:
+  - target: $.info
+    update: [description]
+`))
+	require.NoError(t, err)
+	_, err = o.Apply([]byte(spec))
+	require.ErrorContains(t, err, "can not merge an array into an object")
+}
+
+func TestParseError(t *testing.T) {
+	for doc, msg := range map[string]string{
+		`info: {title: test, version: 1.0.0}
+actions: [{target: $}]`: "missing `overlay` version",
+		`overlay: 2.0.0
+info: {title: test, version: 1.0.0}
+actions: [{target: $}]`: "unsupported overlay version '2.0.0'",
+		`overlay: 1.0.0
+info: {version: 1.0.0}
+actions: [{target: $}]`: "missing `info.title`",
+		`overlay: 1.0.0
+info: {title: test, version: 1.0.0}`: "missing `actions`",
+		`overlay: 1.0.0
+info: {title: test, version: 1.0.0}
+actions: [{remove: true}]`: "action 0: missing `target`",
+		`overlay: 1.0.0
+info: {title: test, version: 1.0.0}
+actions: [{target: "$.paths[", remove: true}]`: "action 0: invalid target",
+	} {
+		_, err := Parse([]byte(doc))
+		require.ErrorContains(t, err, msg)
+	}
+}
+
+// requireSubset checks that every value of expected exists in actual, with arrays and scalars compared as a whole.
+func requireSubset(t *testing.T, expected any, actual any, path string) {
+	t.Helper()
+	e, ok := expected.(map[string]any)
+	if !ok {
+		require.Equal(t, expected, actual, path)
+		return
+	}
+	a, ok := actual.(map[string]any)
+	require.True(t, ok, "%s should be an object", path)
+	for k, v := range e {
+		require.Contains(t, a, k, path)
+		requireSubset(t, v, a[k], path+"."+k)
+	}
+}
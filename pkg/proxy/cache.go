@@ -0,0 +1,217 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/index"
+	"github.com/telkomindonesia/openapi-utils/internal/util"
+)
+
+// docCache shares the upstream docs loaded while compiling a proxy spec. Each proxy using an upstream spec gets
+// its own doc since the operations and components of an upstream doc are pruned and prefixed in place with the name
+// of the proxy. When dir is set, every upstream spec is also bundled once into a single file stored there, which the
+// docs are parsed from, and reused by later compilations until one of their files changes.
+type docCache struct {
+	dir string
+
+	mu    sync.Mutex
+	specs map[specKey]*specEntry
+	docs  map[docKey]*docEntry
+}
+
+// specKey identifies the content of an upstream spec by its absolute path, or its path inside the file system
+// specs are compiled from, and its hash.
+type specKey struct {
+	path string
+	hash string
+}
+
+type specEntry struct {
+	once sync.Once
+	spec []byte
+	err  error
+}
+
+// docKey identifies the doc loaded for the proxy of the given name from an upstream spec.
+type docKey struct {
+	name string
+	spec specKey
+}
+
+type docEntry struct {
+	once sync.Once
+	doc  libopenapi.Document
+	err  error
+}
+
+func newDocCache(dir string) *docCache {
+	return &docCache{dir: dir, specs: map[specKey]*specEntry{}, docs: map[docKey]*docEntry{}}
+}
+
+// load returns the doc of the proxy with the given name for the upstream spec identified by key, calling load
+// the first time it is requested by that proxy. It is safe to call concurrently.
+func (c *docCache) load(name string, key specKey, load func() (libopenapi.Document, error)) (libopenapi.Document, error) {
+	dk := docKey{name: name, spec: key}
+	c.mu.Lock()
+	e, ok := c.docs[dk]
+	if !ok {
+		e = &docEntry{}
+		c.docs[dk] = e
+	}
+	c.mu.Unlock()
+
+	e.once.Do(func() { e.doc, e.err = load() })
+	return e.doc, e.err
+}
+
+// bundledDoc is the on-disk form of an upstream doc.
+type bundledDoc struct {
+	// Files holds the hash of every file the doc was bundled from, keyed by their absolute path.
+	Files map[string]string `json:"files"`
+	Spec  string            `json:"spec"`
+}
+
+// loadBundled returns the doc of the upstream spec identified by key, parsed with config from the spec bundled into
+// a single file. The bundled spec is read from the cache directory, bundling and storing it first when it is missing
+// or one of the files it was bundled from has changed, once per compilation. Failing to store it is only logged.
+func (c *docCache) loadBundled(key specKey, config *datamodel.DocumentConfiguration) (libopenapi.Document, error) {
+	c.mu.Lock()
+	se, ok := c.specs[key]
+	if !ok {
+		se = &specEntry{}
+		c.specs[key] = se
+	}
+	c.mu.Unlock()
+
+	se.once.Do(func() { se.spec, se.err = c.bundle(key.path, config) })
+	if se.err != nil {
+		return nil, se.err
+	}
+	return libopenapi.NewDocumentWithConfiguration(se.spec, config)
+}
+
+func (c *docCache) bundle(specPath string, config *datamodel.DocumentConfiguration) (b []byte, err error) {
+	entryPath := filepath.Join(c.dir, hashOf([]byte(specPath))+".json")
+	if b, ok := readBundledDoc(entryPath); ok {
+		return b, nil
+	}
+
+	doc, err := util.LoadDocumentWithConfiguration(specPath, config)
+	if err != nil {
+		return nil, err
+	}
+	docv3, err := buildUpstreamDoc(doc, specPath)
+	if err != nil {
+		return nil, err
+	}
+	components := util.NewStubComponents()
+	if err = components.CopyAndLocalizeComponents(docv3, ""); err != nil {
+		return nil, fmt.Errorf("fail to copy components: %w", err)
+	}
+	if err = components.CopySecuritySchemes(docv3, ""); err != nil {
+		return nil, fmt.Errorf("fail to copy security schemes: %w", err)
+	}
+	if b, err = components.Render(docv3); err != nil {
+		return nil, fmt.Errorf("fail to bundle openapi spec: %w", err)
+	}
+
+	if files, cacheable := sourceFiles(specPath, docv3.Index.GetRolodex().GetIndexes()); cacheable {
+		if werr := writeBundledDoc(entryPath, bundledDoc{Files: files, Spec: string(b)}); werr != nil && config.Logger != nil {
+			config.Logger.Warn("fail to write upstream spec cache", "spec", specPath, "error", werr)
+		}
+	}
+	return b, nil
+}
+
+// buildUpstreamDoc builds the model of the upstream doc loaded from specPath, reporting its unresolvable references.
+func buildUpstreamDoc(doc libopenapi.Document, specPath string) (*libopenapi.DocumentModel[v3.Document], error) {
+	docv3, err := util.BuildV3Model(doc)
+	if err != nil {
+		return nil, fmt.Errorf("fail to build openapi spec: %w", util.RelocateReferenceErrors(err, doc.GetRolodex(), specPath))
+	}
+	return docv3, nil
+}
+
+// sourceFiles returns the hash of the spec at specPath and of every file referenced by it.
+// Specs having remote references can not be checked for changes, hence are not cacheable.
+func sourceFiles(specPath string, indexes []*index.SpecIndex) (files map[string]string, cacheable bool) {
+	paths := []string{specPath}
+	for _, idx := range indexes {
+		p := idx.GetSpecAbsolutePath()
+		if strings.HasPrefix(p, "http://") || strings.HasPrefix(p, "https://") {
+			return nil, false
+		}
+		paths = append(paths, p)
+	}
+
+	files = make(map[string]string, len(paths))
+	for _, p := range paths {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return nil, false
+		}
+		files[p] = hashOf(b)
+	}
+	return files, true
+}
+
+func readBundledDoc(entryPath string) (spec []byte, ok bool) {
+	b, err := os.ReadFile(entryPath)
+	if err != nil {
+		return nil, false
+	}
+	var bd bundledDoc
+	if err = json.Unmarshal(b, &bd); err != nil {
+		return nil, false
+	}
+	for p, hash := range bd.Files {
+		b, err := os.ReadFile(p)
+		if err != nil || hashOf(b) != hash {
+			return nil, false
+		}
+	}
+	return []byte(bd.Spec), true
+}
+
+// writeBundledDoc writes the entry to a temporary file renamed afterward, so that concurrent
+// compilations never read a partially written entry.
+func writeBundledDoc(entryPath string, bd bundledDoc) (err error) {
+	b, err := json.Marshal(bd)
+	if err != nil {
+		return fmt.Errorf("fail to encode cache entry: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(entryPath), 0o755); err != nil {
+		return fmt.Errorf("fail to create cache directory: %w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(entryPath), ".tmp-")
+	if err != nil {
+		return fmt.Errorf("fail to create cache entry: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.Write(b); err != nil {
+		f.Close()
+		return fmt.Errorf("fail to write cache entry: %w", err)
+	}
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("fail to write cache entry: %w", err)
+	}
+	if err = os.Rename(f.Name(), entryPath); err != nil {
+		return fmt.Errorf("fail to store cache entry: %w", err)
+	}
+	return
+}
+
+func hashOf(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,37 @@
+package proxy
+
+import (
+	"context"
+	"io/fs"
+
+	"github.com/pb33f/libopenapi"
+)
+
+// Compile compiles the proxy spec at the given path into a standalone spec.
+func Compile(ctx context.Context, specPath string, opts ...Option) (newspec []byte, doc libopenapi.Document, err error) {
+	pe, err := NewProxyExtension(ctx, specPath, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	newspec, doc, _, err = pe.CreateProxyDoc()
+	if err != nil {
+		return nil, nil, newError(OpRender, specPath, err)
+	}
+	return
+}
+
+// CompileFS compiles the proxy spec at the given path inside fsys into a standalone spec,
+// reading every upstream spec and its references from fsys.
+func CompileFS(ctx context.Context, fsys fs.FS, specPath string, opts ...Option) (newspec []byte, doc libopenapi.Document, err error) {
+	pe, err := NewProxyExtensionFS(ctx, fsys, specPath, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	newspec, doc, _, err = pe.CreateProxyDoc()
+	if err != nil {
+		return nil, nil, newError(OpRender, specPath, err)
+	}
+	return
+}
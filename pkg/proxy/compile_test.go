@@ -0,0 +1,329 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/stretchr/testify/require"
+	"github.com/telkomindonesia/openapi-utils/internal/testutil"
+	"github.com/telkomindonesia/openapi-utils/pkg/gitfs"
+	"github.com/telkomindonesia/openapi-utils/pkg/reference"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestCompile(t *testing.T) {
+	src := "./testdata/spec-proxy.yml"
+	bytes, _, err := Compile(context.Background(), src)
+	require.NoError(t, err)
+	doc, err := libopenapi.NewDocument(bytes)
+	require.NoError(t, err)
+	_, errs := doc.BuildV3Model()
+	require.NoError(t, errors.Join(errs...))
+
+	golden := filepath.Join("testdata", "spec-proxy.golden.yml")
+	if *update {
+		require.NoError(t, os.WriteFile(golden, bytes, 0644))
+	}
+	expected, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(bytes))
+}
+
+func TestCompileDedupComponents(t *testing.T) {
+	src := "./testdata/spec-proxy.yml"
+	_, doc, err := Compile(context.Background(), src)
+	require.NoError(t, err)
+	docv3, errs := doc.BuildV3Model()
+	require.NoError(t, errors.Join(errs...))
+
+	schemas := docv3.Model.Components.Schemas
+	for _, name := range []string{"UUID", "ZeroableString", "profileZeroableTime", "orderOrder"} {
+		_, ok := schemas.Get(name)
+		require.True(t, ok, "schema %s should exist", name)
+	}
+	for _, name := range []string{"profileUUID", "orderUUID", "profileZeroableString", "orderZeroableString"} {
+		_, ok := schemas.Get(name)
+		require.False(t, ok, "schema %s should be deduplicated", name)
+	}
+
+	responses := docv3.Model.Components.Responses
+	for _, name := range []string{"profileError", "orderError"} {
+		_, ok := responses.Get(name)
+		require.True(t, ok, "response %s should keep its prefix", name)
+	}
+}
+
+func TestCompileMultiFileUpstream(t *testing.T) {
+	src := "./testdata/spec-proxy.yml"
+	_, doc, err := Compile(context.Background(), src)
+	require.NoError(t, err)
+	docv3, errs := doc.BuildV3Model()
+	require.NoError(t, errors.Join(errs...))
+
+	path, ok := docv3.Model.Paths.PathItems.Get("/tenant")
+	require.True(t, ok, "path should be present")
+	require.NotNil(t, path.Get, "operation should be present")
+	require.Equal(t, "GetTenant", path.Get.OperationId)
+	_, ok = docv3.Model.Components.Schemas.Get("tenantTenant")
+	require.True(t, ok, "schema from referenced file should be copied")
+}
+
+func TestCompileError(t *testing.T) {
+	src := "./testdata/not-exist.yml"
+	_, _, err := Compile(context.Background(), src)
+	var e *Error
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, OpLoad, e.Op)
+	require.Equal(t, src, e.Path)
+}
+
+func TestCompileReferenceErrors(t *testing.T) {
+	src := "testdata/broken/spec-proxy.yml"
+	spec, err := filepath.Abs("testdata/broken/spec-item.yml")
+	require.NoError(t, err)
+
+	for name, opts := range map[string][]Option{
+		"default": nil,
+		"cache":   {WithCacheDir(t.TempDir())},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := Compile(context.Background(), src, opts...)
+			var errs reference.Errors
+			require.ErrorAs(t, err, &errs)
+			require.Len(t, errs, 1)
+			require.Equal(t, spec, errs[0].File)
+			require.Equal(t, 21, errs[0].Line)
+			require.Equal(t, "#/components/schemas/Gone", errs[0].Ref)
+		})
+	}
+
+	_, _, err = CompileFS(context.Background(), os.DirFS("testdata/broken"), "spec-proxy.yml")
+	var errs reference.Errors
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs, 1)
+	require.Equal(t, "spec-item.yml", errs[0].File)
+	require.Equal(t, 21, errs[0].Line)
+}
+
+func TestCompileFS(t *testing.T) {
+	fsys := testutil.MapFS(t, "./testdata", "specs")
+	bytes, _, err := CompileFS(context.Background(), fsys, "specs/spec-proxy.yml")
+	require.NoError(t, err)
+	expected, _, err := Compile(context.Background(), "./testdata/spec-proxy.yml")
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(bytes))
+}
+
+func TestCompileFSGit(t *testing.T) {
+	ctx := context.Background()
+	repo := testutil.GitRepo(t, "./testdata", "specs", "v1")
+	fsys, err := gitfs.New(ctx, repo, "v1")
+	require.NoError(t, err)
+	defer fsys.Close()
+
+	bytes, _, err := CompileFS(ctx, fsys, "specs/spec-proxy.yml")
+	require.NoError(t, err)
+	expected, _, err := Compile(ctx, "./testdata/spec-proxy.yml")
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(bytes))
+}
+
+func TestCompileConcurrency(t *testing.T) {
+	src := writeSyntheticSpecs(t, t.TempDir(), 4, 5)
+	expected, _, err := Compile(context.Background(), src, WithConcurrency(1))
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		bytes, _, err := Compile(context.Background(), src, WithConcurrency(4))
+		require.NoError(t, err)
+		require.Equal(t, string(expected), string(bytes), "output should not depend on the number of workers")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = Compile(ctx, src, WithConcurrency(4))
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestCompileCache(t *testing.T) {
+	dir := t.TempDir()
+	testutil.CopyDir(t, "./testdata", dir)
+	src := filepath.Join(dir, "spec-proxy.yml")
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+
+	pe, err := NewProxyExtension(context.Background(), src, WithCacheDir(cacheDir))
+	require.NoError(t, err)
+	require.Len(t, pe.cache.specs, 3, "upstream specs should be bundled once")
+	require.Len(t, pe.cache.docs, 3, "upstream docs should be parsed once per proxy")
+	entries, err := os.ReadDir(cacheDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 3, "every upstream doc should be stored")
+
+	expected, _, err := Compile(context.Background(), src)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		bytes, _, err := Compile(context.Background(), src, WithCacheDir(cacheDir))
+		require.NoError(t, err)
+		require.Equal(t, string(expected), string(bytes))
+	}
+
+	// changing a file referenced by an upstream doc invalidates its entry
+	components := filepath.Join(dir, "tenant", "components", "tenant.yml")
+	b, err := os.ReadFile(components)
+	require.NoError(t, err)
+	err = os.WriteFile(components, []byte(strings.Replace(string(b), "format: uuid\n", "format: uuid\n      maxLength: 36\n", 1)), 0o644)
+	require.NoError(t, err)
+
+	expected, _, err = Compile(context.Background(), src)
+	require.NoError(t, err)
+	require.Contains(t, string(expected), "maxLength: 36")
+	bytes, _, err := Compile(context.Background(), src, WithCacheDir(cacheDir))
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(bytes))
+}
+
+func TestCompileSharedUpstream(t *testing.T) {
+	dir := t.TempDir()
+	writeSyntheticSpecs(t, dir, 1, 1)
+	src := filepath.Join(dir, "shared.yml")
+	err := os.WriteFile(src, []byte(`openapi: "3.0.0"
+info:
+  title: Shared Proxy
+  version: "1.0.0"
+paths:
+  /a/items/{id}:
+    get:
+      x-proxy:
+        name: a
+        path: /items0/{id}
+        method: get
+  /b/items/{id}:
+    get:
+      x-proxy:
+        name: b
+        path: /items0/{id}
+        method: get
+components:
+  x-proxy:
+    a:
+      spec: ./svc0.yml
+    b:
+      spec: ./svc0.yml
+`), 0o644)
+	require.NoError(t, err)
+
+	pe, err := NewProxyExtension(context.Background(), src)
+	require.NoError(t, err)
+	require.Empty(t, pe.cache.specs, "upstream specs should only be bundled for the cache directory")
+	require.Len(t, pe.cache.docs, 2, "every proxy should get its own upstream doc")
+
+	_, doc, _, err := pe.CreateProxyDoc()
+	require.NoError(t, err)
+	docv3, errs := doc.BuildV3Model()
+	require.NoError(t, errors.Join(errs...))
+	for _, p := range []string{"/a/items/{id}", "/b/items/{id}"} {
+		pathItem, ok := docv3.Model.Paths.PathItems.Get(p)
+		require.True(t, ok, "path %s should exist", p)
+		require.NotNil(t, pathItem.Get.Responses)
+	}
+}
+
+func BenchmarkCompile(b *testing.B) {
+	src := writeSyntheticSpecs(b, b.TempDir(), 16, 20)
+	for _, concurrency := range []int{1, 2, 4, 8} {
+		b.Run("concurrency="+strconv.Itoa(concurrency), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _, err := Compile(context.Background(), src, WithConcurrency(concurrency),
+					WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+func BenchmarkCompileSpecSize(b *testing.B) {
+	for _, paths := range []int{10, 40, 160} {
+		src := writeSyntheticSpecs(b, b.TempDir(), 4, paths)
+		b.Run("paths="+strconv.Itoa(paths), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _, err := Compile(context.Background(), src, WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+// writeSyntheticSpecs writes a proxy spec proxying every operation of the given number of upstream specs,
+// each having the given number of paths, and returns its path.
+func writeSyntheticSpecs(tb testing.TB, dir string, services int, paths int) string {
+	proxy := strings.Builder{}
+	proxy.WriteString("openapi: \"3.0.0\"\ninfo:\n  title: Synthetic Proxy\n  version: \"1.0.0\"\npaths:\n")
+	for i := 0; i < services; i++ {
+		svc := "svc" + strconv.Itoa(i)
+		spec := strings.Builder{}
+		spec.WriteString("openapi: \"3.0.0\"\ninfo:\n  title: " + svc + "\n  version: \"1.0.0\"\npaths:\n")
+		schemas := strings.Builder{}
+		schemas.WriteString("components:\n  schemas:\n    ID:\n      type: string\n      format: uuid\n")
+		for j := 0; j < paths; j++ {
+			item := "Item" + strconv.Itoa(j)
+			upath := "/items" + strconv.Itoa(j) + "/{id}"
+			fmt.Fprintf(&spec, `  %s:
+    get:
+      operationId: Get%s
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            $ref: "#/components/schemas/ID"
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/%s"
+`, upath, item, item)
+			fmt.Fprintf(&schemas, `    %s:
+      properties:
+        id:
+          $ref: "#/components/schemas/ID"
+        name:
+          type: string
+        tags:
+          type: array
+          items:
+            type: string
+`, item)
+			fmt.Fprintf(&proxy, `  /%s%s:
+    get:
+      operationId: %sGet%s
+      x-proxy:
+        name: %s
+        path: %s
+        method: get
+`, svc, upath, svc, item, svc, upath)
+		}
+
+		spec.WriteString(schemas.String())
+		require.NoError(tb, os.WriteFile(filepath.Join(dir, svc+".yml"), []byte(spec.String()), 0o644))
+	}
+
+	proxy.WriteString("components:\n  x-proxy:\n")
+	for i := 0; i < services; i++ {
+		fmt.Fprintf(&proxy, "    svc%d:\n      spec: ./svc%d.yml\n", i, i)
+	}
+	src := filepath.Join(dir, "proxy.yml")
+	require.NoError(tb, os.WriteFile(src, []byte(proxy.String()), 0o644))
+	return src
+}
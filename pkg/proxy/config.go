@@ -3,7 +3,6 @@ package proxy
 import (
 	"errors"
 	"fmt"
-	"os"
 	"path"
 	"regexp"
 	"strings"
@@ -16,28 +15,29 @@ import (
 type Proxies map[string]Proxy
 
 type Proxy struct {
-	Name string `json:"name" yaml:"name"`
-	Spec string `json:"spec" yaml:"spec"`
+	Name     string            `json:"name" yaml:"name"`
+	Spec     string            `json:"spec" yaml:"spec"`
+	Security []SecurityMapping `json:"security,omitempty" yaml:"security,omitempty"`
 
-	doc libopenapi.Document
+	Server       *ServerSelection       `json:"server,omitempty" yaml:"server,omitempty"`
+	Environments map[string]Environment `json:"environments,omitempty" yaml:"environments,omitempty"`
+
+	doc    libopenapi.Document
+	loader func(specPath string) (libopenapi.Document, error)
 }
 
 func (p *Proxy) buildOpenapiDocument() (err error) {
-	var b []byte
-	b, err = os.ReadFile(p.Spec)
-	if err != nil {
-		err = fmt.Errorf("fail to read openapi spec: %w", err)
-		return
+	load := util.LoadDocument
+	if p.loader != nil {
+		load = p.loader
 	}
-
-	doc, err := libopenapi.NewDocument(b)
+	doc, err := load(p.Spec)
 	if err != nil {
 		return fmt.Errorf("fail to build openapi doc: %w", err)
 	}
 
-	_, errs := doc.BuildV3Model()
-	if err = errors.Join(errs...); err != nil {
-		return fmt.Errorf("fail to build v3 openapi doc: %w", err)
+	if _, err = util.BuildV3Model(doc); err != nil {
+		return fmt.Errorf("fail to build v3 openapi doc: %w", util.RelocateReferenceErrors(err, doc.GetRolodex(), p.Spec))
 	}
 
 	p.doc = doc
@@ -83,9 +83,11 @@ type ProxyOperation struct {
 	Method string `json:"method" yaml:"method"`
 	Inject Inject `json:"inject" yaml:"inject"`
 
-	up      *v3.PathItem
-	uop     *v3.Operation
-	uparams []*v3.Parameter
+	up       *v3.PathItem
+	uop      *v3.Operation
+	uparams  []*v3.Parameter
+	security []SecurityMapping
+	server   string
 }
 
 func (pop ProxyOperation) WithReloadedDoc(doc libopenapi.Document) ProxyOperation {
@@ -93,13 +95,18 @@ func (pop ProxyOperation) WithReloadedDoc(doc libopenapi.Document) ProxyOperatio
 		Proxy: &Proxy{
 			doc: doc,
 		},
-		Path:   pop.Path,
-		Method: pop.Method,
-		Inject: pop.Inject,
+		Path:     pop.Path,
+		Method:   pop.Method,
+		Inject:   pop.Inject,
+		security: pop.security,
+		server:   pop.server,
 	}
 	if pop.Proxy != nil {
 		npop.Name = pop.Name
 		npop.Spec = pop.Spec
+		npop.Security = pop.Security
+		npop.Server = pop.Server
+		npop.Environments = pop.Environments
 	}
 	return npop
 }
@@ -158,4 +165,7 @@ type Inject struct {
 type ExcludedParameter struct {
 	Name string `json:"name,omitempty" yaml:"name,omitempty"`
 	In   string `json:"in,omitempty" yaml:"in,omitempty"`
+
+	// Value is the value injected by the gateway in place of the excluded parameter.
+	Value string `json:"value,omitempty" yaml:"value,omitempty"`
 }
@@ -0,0 +1,5 @@
+// Package proxy compiles an OpenAPI 3 spec whose operations are declared with the `x-proxy` extension
+// into a standalone spec by copying the proxied upstream operations and the components they reference.
+// Every proxied operation declares the upstream server selected for the environment in its `x-proxy-server`
+// extension, its `servers` are the ones of the proxy spec so that clients keep calling the proxy.
+package proxy
@@ -0,0 +1,32 @@
+package proxy
+
+const (
+	OpLoad    = "load"
+	OpCompile = "compile"
+	OpRender  = "render"
+)
+
+// Error is the error returned when compiling a proxy spec. Its Op is either OpLoad, OpCompile or
+// OpRender, and its Path is the path of the proxy spec.
+type Error struct {
+	// Op is the operation that failed.
+	Op string
+	// Path is the path of the spec being processed, if any.
+	Path string
+	Err  error
+}
+
+func newError(op string, path string, err error) *Error {
+	return &Error{Op: op, Path: path, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Path == "" {
+		return "fail to " + e.Op + ": " + e.Err.Error()
+	}
+	return "fail to " + e.Op + " " + e.Path + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,509 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+
+	"github.com/pb33f/libopenapi"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"github.com/telkomindonesia/openapi-utils/internal/util"
+	"golang.org/x/sync/errgroup"
+	"gopkg.in/yaml.v3"
+)
+
+type ProxyExtension struct {
+	fsys     fs.FS
+	specPath string
+	specDir  string
+	opts     Options
+
+	doc      libopenapi.Document
+	docv3    *libopenapi.DocumentModel[v3.Document]
+	proxied  map[*v3.Operation]*ProxyOperation
+	upstream map[libopenapi.Document]map[*v3.Operation]map[*ProxyOperation]struct{}
+	// components holds the prefixed components used by the operations of every upstream doc.
+	components map[libopenapi.Document]util.PrefixedComponents
+	cache      *docCache
+}
+
+func NewProxyExtension(ctx context.Context, specPath string, opts ...Option) (pe ProxyExtension, err error) {
+	pe.opts = Options{BasePath: filepath.Dir(specPath)}
+	for _, opt := range opts {
+		opt(&pe.opts)
+	}
+	pe.specPath = specPath
+	pe.specDir, err = filepath.Abs(pe.opts.BasePath)
+	if err != nil {
+		return pe, newError(OpLoad, specPath, fmt.Errorf("fail to determine spec file base directory: %w", err))
+	}
+	err = pe.init(ctx)
+	return
+}
+
+// NewProxyExtensionFS is like NewProxyExtension but reads the proxy spec at the given path inside fsys
+// and resolves every upstream spec and its references from fsys. The BasePath option is
+// then a path inside fsys and the LocalFS option is ignored. References pointing outside of
+// the root directory of fsys can not be resolved.
+func NewProxyExtensionFS(ctx context.Context, fsys fs.FS, specPath string, opts ...Option) (pe ProxyExtension, err error) {
+	pe.opts = Options{BasePath: path.Dir(specPath)}
+	for _, opt := range opts {
+		opt(&pe.opts)
+	}
+	pe.opts.LocalFS = nil
+	pe.fsys = fsys
+	pe.specPath = specPath
+	pe.specDir = path.Clean(pe.opts.BasePath)
+	err = pe.init(ctx)
+	return
+}
+
+func (pe *ProxyExtension) init(ctx context.Context) (err error) {
+	specPath := pe.specPath
+	pe.cache = newDocCache(pe.opts.CacheDir)
+	if err = pe.loadDoc(); err != nil {
+		return newError(OpLoad, specPath, err)
+	}
+	if err = pe.loadProxy(ctx); err != nil {
+		return newError(OpLoad, specPath, err)
+	}
+	if err = pe.pruneAndPrefixUpstream(ctx); err != nil {
+		return newError(OpCompile, specPath, err)
+	}
+	if err = pe.compile(); err != nil {
+		return newError(OpCompile, specPath, err)
+	}
+
+	return
+}
+
+func (pe *ProxyExtension) loadDoc() (err error) {
+	var specBytes []byte
+	if pe.fsys != nil {
+		specBytes, err = fs.ReadFile(pe.fsys, pe.specPath)
+	} else {
+		specBytes, err = os.ReadFile(pe.specPath)
+	}
+	if err != nil {
+		return fmt.Errorf("fail to read spec file: %w", err)
+	}
+
+	doc, err := libopenapi.NewDocument([]byte(specBytes))
+	if err != nil {
+		return fmt.Errorf("failed to create openapi document: %w", err)
+	}
+	docv3, errs := doc.BuildV3Model()
+	if err = errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to create openapi v3 document: %w", err)
+	}
+
+	pe.doc, pe.docv3 = doc, docv3
+	return
+}
+
+// upstreamLoader returns the loader of the upstream docs used by the given proxy. Docs are shared through
+// the cache by the operations of the proxy, while every proxy gets its own copy of an upstream spec.
+func (pe *ProxyExtension) upstreamLoader(p *Proxy) func(specPath string) (libopenapi.Document, error) {
+	return func(specPath string) (libopenapi.Document, error) {
+		return pe.loadUpstreamDoc(p.GetName(), specPath)
+	}
+}
+
+func (pe *ProxyExtension) loadUpstreamDoc(name string, specPath string) (libopenapi.Document, error) {
+	key := specKey{path: specPath}
+	var b []byte
+	var err error
+	if pe.fsys != nil {
+		b, err = fs.ReadFile(pe.fsys, specPath)
+	} else if key.path, err = filepath.Abs(specPath); err == nil {
+		b, err = os.ReadFile(specPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fail to read file: %w", err)
+	}
+	key.hash = hashOf(b)
+
+	dir := filepath.Dir(key.path)
+	if pe.fsys != nil {
+		dir = path.Dir(specPath)
+	}
+	// the doc is pruned and prefixed in place later on, hence loaded as is from the files of the spec
+	return pe.cache.load(name, key, func() (libopenapi.Document, error) {
+		config := pe.opts.documentConfiguration(dir)
+		switch {
+		case pe.fsys != nil:
+			return util.LoadDocumentFS(pe.fsys, specPath, config)
+		case pe.cache.dir != "" && pe.opts.LocalFS == nil:
+			return pe.cache.loadBundled(key, config)
+		}
+		doc, err := util.LoadDocumentWithConfiguration(specPath, config)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = buildUpstreamDoc(doc, specPath); err != nil {
+			return nil, err
+		}
+		return doc, nil
+	})
+}
+
+func (pe *ProxyExtension) loadProxy(ctx context.Context) (err error) {
+	pe.proxied = map[*v3.Operation]*ProxyOperation{}
+	pe.upstream = make(map[libopenapi.Document]map[*v3.Operation]map[*ProxyOperation]struct{})
+
+	proxies := map[string]*Proxy{}
+	if pe.docv3.Model.Components.Extensions != nil {
+		ex, ok := pe.docv3.Model.Components.Extensions.Get("x-proxy")
+		if ok {
+			if err = ex.Decode(proxies); err != nil {
+				return fmt.Errorf("fail to decode `x-proxy` component :%w", err)
+			}
+
+			for k, v := range proxies {
+				v.Name = k
+				v.Spec = path.Join(pe.specDir, v.Spec)
+				v.loader = pe.upstreamLoader(v)
+			}
+		}
+	}
+
+	// resolve the proxy of every operation first so that distinct upstream docs can be loaded concurrently
+	type proxied struct {
+		op  *v3.Operation
+		pop *ProxyOperation
+		sec []SecurityMapping
+		srv Proxy
+	}
+	var pops []proxied
+	var upstreams []*Proxy
+	seen := map[*Proxy]struct{}{}
+	for m := range orderedmap.Iterate(ctx, pe.docv3.Model.Paths.PathItems) {
+		for _, op := range util.GetOperationsMap(m.Value()) {
+			if op.Extensions == nil {
+				continue
+			}
+			ex, ok := op.Extensions.Get("x-proxy")
+			if !ok {
+				continue
+			}
+
+			var pop ProxyOperation
+			if err = ex.Decode(&pop); err != nil {
+				return fmt.Errorf("fail to decode Proxy Operation : %w", err)
+			}
+			var security []SecurityMapping
+			var server Proxy
+			if pop.Spec == "" && pop.Proxy != nil && pop.Proxy.Name != "" {
+				// the security and server of the operation override the ones of the named proxy
+				security = pop.Proxy.Security
+				server = Proxy{Server: pop.Proxy.Server, Environments: pop.Proxy.Environments}
+				name := pop.Name
+				pop.Proxy, ok = proxies[name]
+				if !ok {
+					return fmt.Errorf("invalid proxy definition for %s: no spec is provided", name)
+				}
+			} else {
+				pop.Spec = path.Join(pe.specDir, pop.Spec)
+				pop.loader = pe.upstreamLoader(pop.Proxy)
+			}
+
+			pops = append(pops, proxied{op: op, pop: &pop, sec: security, srv: server})
+			if _, ok := seen[pop.Proxy]; !ok {
+				seen[pop.Proxy] = struct{}{}
+				upstreams = append(upstreams, pop.Proxy)
+			}
+		}
+	}
+	err = pe.parallel(ctx, len(upstreams), func(i int) error {
+		if _, err := upstreams[i].GetOpenAPIDoc(); err != nil {
+			return fmt.Errorf("fail to load upstream openapi spec: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	envFound := pe.opts.Environment == ""
+	for _, p := range pops {
+		pop := p.pop
+		doc, err := pop.GetOpenAPIDoc()
+		if err != nil {
+			return fmt.Errorf("fail to load upstream openapi spec: %w", err)
+		}
+		uop, err := pop.GetUpstreamOperation()
+		if err != nil {
+			return fmt.Errorf("fail to find upstream operation: %w", err)
+		}
+		if err = pop.resolveSecurity(p.sec, &pe.docv3.Model); err != nil {
+			return fmt.Errorf("invalid security mapping for '%s %s': %w", pop.Method, pop.Path, err)
+		}
+		if err = pop.resolveServer(p.srv, pe.opts.Environment); err != nil {
+			return fmt.Errorf("invalid server selection for '%s %s': %w", pop.Method, pop.Path, err)
+		}
+		if _, ok := p.srv.Environments[pe.opts.Environment]; ok {
+			envFound = true
+		}
+		if _, ok := pop.Environments[pe.opts.Environment]; ok {
+			envFound = true
+		}
+
+		pe.proxied[p.op] = pop
+		if _, ok := pe.upstream[doc]; !ok {
+			pe.upstream[doc] = map[*v3.Operation]map[*ProxyOperation]struct{}{}
+		}
+		if _, ok := pe.upstream[doc][uop]; !ok {
+			pe.upstream[doc][uop] = map[*ProxyOperation]struct{}{}
+		}
+		pe.upstream[doc][uop][pop] = struct{}{}
+	}
+	if !envFound {
+		return fmt.Errorf("environment '%s' is not defined by any proxy", pe.opts.Environment)
+	}
+	return
+}
+
+func (pe *ProxyExtension) pruneAndPrefixUpstream(ctx context.Context) (err error) {
+	// upstream docs are independent of each other, they are processed concurrently in the order of their spec
+	docs := make([]libopenapi.Document, 0, len(pe.upstream))
+	for doc := range pe.upstream {
+		docs = append(docs, doc)
+	}
+	sort.SliceStable(docs, func(i, j int) bool {
+		return upstreamSpec(pe.upstream[docs[i]]) < upstreamSpec(pe.upstream[docs[j]])
+	})
+	components := make([]util.PrefixedComponents, len(docs))
+	err = pe.parallel(ctx, len(docs), func(i int) (err error) {
+		components[i], err = pruneAndPrefixUpstreamDoc(ctx, docs[i], pe.upstream[docs[i]])
+		return
+	})
+	if err != nil {
+		return
+	}
+
+	pe.components = make(map[libopenapi.Document]util.PrefixedComponents, len(docs))
+	for i, doc := range docs {
+		pe.components[doc] = components[i]
+	}
+	return
+}
+
+// pruneAndPrefixUpstreamDoc copies, with the proxy name as prefix, the components reachable from the used operations
+// of the upstream doc, and localizes the references of those operations so that they render pointing to the copies.
+// The doc is modified in place, it is never rendered and reloaded.
+func pruneAndPrefixUpstreamDoc(ctx context.Context, doc libopenapi.Document, uopPopMap map[*v3.Operation]map[*ProxyOperation]struct{}) (pc util.PrefixedComponents, err error) {
+	docv3, _ := doc.BuildV3Model()
+	prefix := util.MapFirstEntry(util.MapFirstEntry(uopPopMap).Value).Key.GetName()
+
+	// add prefix to operation id.
+	// schemas are built lazily, render them once before their references get localized.
+	for uop, popmap := range uopPopMap {
+		uop.OperationId = util.MapFirstEntry(popmap).Key.GetName() + uop.OperationId
+		if _, err = uop.Render(); err != nil {
+			return pc, fmt.Errorf("fail to render upstream operation: %w", err)
+		}
+	}
+
+	// only the used operations are kept, the way the filter command drops the other ones
+	util.DeleteOperations(ctx, &docv3.Model, func(_ string, _ string, _ *v3.PathItem, op *v3.Operation) bool {
+		_, ok := uopPopMap[op]
+		return ok
+	})
+
+	// collect the nodes of the used operations and their path items parameters, in document order
+	var roots []*yaml.Node
+	for m := range orderedmap.Iterate(ctx, docv3.Model.Paths.PathItems) {
+		nodes := []*yaml.Node{}
+		for _, p := range m.Value().Parameters {
+			if _, err = p.Render(); err != nil {
+				return pc, fmt.Errorf("fail to render upstream parameter: %w", err)
+			}
+		}
+		if n := m.Value().GoLow().Parameters.ValueNode; n != nil {
+			nodes = append(nodes, n)
+		}
+		for _, uop := range util.GetOperationsMap(m.Value()) {
+			nodes = append(nodes, uop.GoLow().RootNode)
+		}
+		sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].Line < nodes[j].Line })
+		roots = append(roots, nodes...)
+	}
+
+	// references are localized since the rendered doc no longer lives next to the files it referenced.
+	components := util.NewStubComponents()
+	err = components.CopyAndLocalizeReachableComponents(docv3, prefix, roots...)
+	if err != nil {
+		return pc, fmt.Errorf("fail to copy components with prefix: %w", err)
+	}
+	return util.PrefixedComponents{Prefix: prefix, Components: components}, nil
+}
+
+func upstreamSpec(uopPopMap map[*v3.Operation]map[*ProxyOperation]struct{}) string {
+	return util.MapFirstEntry(util.MapFirstEntry(uopPopMap).Value).Key.Spec
+}
+
+// parallel calls fn for every index below n using at most Options.Concurrency goroutines.
+// Once ctx is done or fn fails, the remaining indexes are skipped. The error reported is the
+// one of the lowest failing index so that failures are reported the same way on every run.
+func (pe *ProxyExtension) parallel(ctx context.Context, n int, fn func(i int) error) error {
+	limit := pe.opts.Concurrency
+	if limit <= 0 {
+		limit = runtime.GOMAXPROCS(0)
+	}
+
+	errs := make([]error, n)
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(limit)
+	for i := 0; i < n; i++ {
+		i := i
+		g.Go(func() error {
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+			errs[i] = fn(i)
+			return errs[i]
+		})
+	}
+	gerr := g.Wait()
+	// a done ctx is reported even with nothing left to process, the iterations over the paths stopping silently
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return gerr
+}
+
+// compile proxy document
+func (pe *ProxyExtension) compile() (err error) {
+	for op, pop := range pe.proxied {
+		uop, err := pop.GetUpstreamOperation()
+		if err != nil {
+			return fmt.Errorf("fail to get upstream operation: %w", err)
+		}
+		params, err := pop.GetProxiedParameters()
+		if err != nil {
+			return fmt.Errorf("fail to get proxied parameter: %w", err)
+		}
+
+		// copy operation
+		opParam := util.CopyParameters(op.Parameters, params...)
+		if len(opParam) == 0 {
+			// libopenapi fails to render empty slice
+			opParam = nil
+		}
+		opID := op.OperationId
+		opSecurity := op.Security
+		opExt := op.Extensions
+		opServers := op.Servers
+		*op = *uop
+		op.Parameters = opParam
+		op.OperationId = opID
+		op.Security = opSecurity
+		for m := range orderedmap.Iterate(context.Background(), op.Extensions) {
+			opExt.Set(m.Key(), m.Value())
+		}
+		sec, err := pop.securityExtension()
+		if err != nil {
+			return fmt.Errorf("fail to encode security mapping: %w", err)
+		}
+		if sec != nil {
+			opExt.Set("x-proxy-security", sec)
+		}
+		// clients keep calling the proxy, the upstream servers are only known to the proxy itself
+		op.Servers = opServers
+		if u := pop.GetUpstreamServerURL(); u != "" {
+			opExt.Set("x-proxy-server", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: u})
+		}
+		op.Extensions = opExt
+	}
+
+	return
+}
+
+func (pe *ProxyExtension) Proxied() map[*v3.Operation]*ProxyOperation {
+	return pe.proxied
+}
+
+func (pe *ProxyExtension) GetOpenAPIV3Doc() *libopenapi.DocumentModel[v3.Document] {
+	return pe.docv3
+}
+
+// CreateProxyDoc renders the compiled proxy spec together with the components of the upstream docs. It is the only
+// time the compilation renders a doc, which is then parsed back into the returned doc.
+func (pe *ProxyExtension) CreateProxyDoc() (b []byte, ndoc libopenapi.Document, docv3 *libopenapi.DocumentModel[v3.Document], err error) {
+	components := util.NewStubComponents()
+	err = components.CopyComponents(pe.docv3, "")
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("fail to copy components on proxy doc: %w", err)
+	}
+	err = components.CopySecuritySchemes(pe.docv3, "")
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("fail to copy security schemes on proxy doc: %w", err)
+	}
+
+	upstreams := make([]util.PrefixedComponents, 0, len(pe.components))
+	for _, pc := range pe.components {
+		upstreams = append(upstreams, pc)
+	}
+	sort.SliceStable(upstreams, func(i, j int) bool { return upstreams[i].Prefix < upstreams[j].Prefix })
+
+	// components identical across upstream docs are emitted once without prefix
+	refs := util.MergeComponents(components, upstreams...)
+
+	// copy upstream security schemes used by security mappings
+	schemes := map[string]*v3.SecurityScheme{}
+	for _, pop := range pe.proxied {
+		for _, m := range pop.GetSecurityMappings() {
+			if m.GetUpstreamSecurityScheme() != nil {
+				schemes[pop.GetName()+m.Upstream] = m.GetUpstreamSecurityScheme()
+			}
+		}
+	}
+	names := make([]string, 0, len(schemes))
+	for name := range schemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		n, err := schemes[name].MarshalYAML()
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("fail to render upstream security scheme: %w", err)
+		}
+		components.SecuritySchemes.Set(name, n.(*yaml.Node))
+	}
+
+	docv3, errs := pe.doc.BuildV3Model()
+	if err = errors.Join(errs...); err != nil {
+		return nil, nil, nil, fmt.Errorf("fail to build v3 model: %w", err)
+	}
+	root, err := components.RenderNode(docv3)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("fail to render doc: %w", err)
+	}
+	util.RewriteReferences(root, refs)
+	b, err = yaml.Marshal(root)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("fail to marshal doc: %w", err)
+	}
+
+	ndoc, err = libopenapi.NewDocumentWithConfiguration(b, pe.doc.GetConfiguration())
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("fail to parse new doc: %w", err)
+	}
+	docv3, errs = ndoc.BuildV3Model()
+	if err = errors.Join(errs...); err != nil {
+		return nil, nil, nil, fmt.Errorf("fail to build v3 model from new doc: %w", err)
+	}
+	return
+}
@@ -0,0 +1,77 @@
+package proxy
+
+import (
+	"io/fs"
+	"log/slog"
+
+	"github.com/pb33f/libopenapi/datamodel"
+	"github.com/telkomindonesia/openapi-utils/internal/util"
+)
+
+// Options holds the settings used to compile a proxy spec.
+type Options struct {
+	// Environment selects the upstream servers declared under `x-proxy` environments.
+	Environment string
+	// Logger receives warnings emitted while resolving references of upstream specs.
+	Logger *slog.Logger
+	// BasePath is the directory `x-proxy` spec paths are resolved from.
+	// It defaults to the directory of the proxy spec.
+	BasePath string
+	// LocalFS is the filesystem relative references of upstream specs are read from.
+	// It defaults to the local disk.
+	LocalFS fs.FS
+	// Concurrency bounds the number of upstream specs loaded and processed at the same time.
+	// It defaults to GOMAXPROCS.
+	Concurrency int
+	// CacheDir is the directory upstream specs are stored in, bundled into a single file, so that
+	// later compilations reuse them as long as none of their files changed. Caching is disabled when empty.
+	// It is ignored when LocalFS is set or when compiling from a file system.
+	CacheDir string
+}
+
+type Option func(*Options)
+
+// WithEnvironment selects the environment used to resolve the upstream servers.
+func WithEnvironment(env string) Option {
+	return func(o *Options) {
+		o.Environment = env
+	}
+}
+
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+func WithBasePath(basePath string) Option {
+	return func(o *Options) {
+		o.BasePath = basePath
+	}
+}
+
+func WithLocalFS(fsys fs.FS) Option {
+	return func(o *Options) {
+		o.LocalFS = fsys
+	}
+}
+
+// WithConcurrency bounds the number of upstream specs processed at the same time, see Options.Concurrency.
+func WithConcurrency(n int) Option {
+	return func(o *Options) {
+		o.Concurrency = n
+	}
+}
+
+// WithCacheDir stores the bundled upstream specs in the given directory, see Options.CacheDir.
+func WithCacheDir(dir string) Option {
+	return func(o *Options) {
+		o.CacheDir = dir
+	}
+}
+
+// documentConfiguration returns the configuration used to load an upstream spec,
+// which may reference the files of a remote upstream.
+func (o Options) documentConfiguration(basePath string) *datamodel.DocumentConfiguration {
+	return util.LoadOptions{Logger: o.Logger, BasePath: basePath, LocalFS: o.LocalFS, AllowRemoteReferences: true}.DocumentConfiguration()
+}
@@ -0,0 +1,85 @@
+package proxy
+
+import (
+	"fmt"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// SecurityMapping declares how a security scheme required by the proxy
+// translates into a security scheme required by the upstream.
+type SecurityMapping struct {
+	Proxy    string `json:"proxy" yaml:"proxy"`
+	Upstream string `json:"upstream,omitempty" yaml:"upstream,omitempty"`
+
+	upstreamScheme *v3.SecurityScheme
+}
+
+func (sm SecurityMapping) GetUpstreamSecurityScheme() *v3.SecurityScheme {
+	return sm.upstreamScheme
+}
+
+func (pop *ProxyOperation) GetSecurityMappings() []SecurityMapping {
+	return pop.security
+}
+
+func (pop *ProxyOperation) resolveSecurity(override []SecurityMapping, docv3 *v3.Document) (err error) {
+	mappings := override
+	if mappings == nil && pop.Proxy != nil {
+		mappings = pop.Proxy.Security
+	}
+	if len(mappings) == 0 {
+		return
+	}
+
+	udoc, err := pop.GetOpenAPIV3Doc()
+	if err != nil {
+		return fmt.Errorf("fail to load upstream doc: %w", err)
+	}
+
+	pop.security = make([]SecurityMapping, 0, len(mappings))
+	for _, m := range mappings {
+		if m.Proxy == "" {
+			return fmt.Errorf("proxy security scheme is required")
+		}
+		if getSecurityScheme(docv3, m.Proxy) == nil {
+			return fmt.Errorf("security scheme '%s' not found inside proxy doc", m.Proxy)
+		}
+		if m.Upstream != "" {
+			m.upstreamScheme = getSecurityScheme(&udoc.Model, m.Upstream)
+			if m.upstreamScheme == nil {
+				return fmt.Errorf("security scheme '%s' not found inside upstream doc", m.Upstream)
+			}
+		}
+		pop.security = append(pop.security, m)
+	}
+	return
+}
+
+func getSecurityScheme(doc *v3.Document, name string) *v3.SecurityScheme {
+	if doc.Components == nil || doc.Components.SecuritySchemes == nil {
+		return nil
+	}
+	s, _ := doc.Components.SecuritySchemes.Get(name)
+	return s
+}
+
+// securityExtension creates the `x-proxy-security` extension describing the mapping
+// with upstream scheme names matching the ones copied into the proxy doc.
+func (pop *ProxyOperation) securityExtension() (n *yaml.Node, err error) {
+	if len(pop.security) == 0 {
+		return nil, nil
+	}
+
+	mappings := make([]SecurityMapping, 0, len(pop.security))
+	for _, m := range pop.security {
+		if m.Upstream != "" {
+			m.Upstream = pop.GetName() + m.Upstream
+		}
+		mappings = append(mappings, m)
+	}
+
+	n = &yaml.Node{}
+	return n, n.Encode(mappings)
+}
@@ -0,0 +1,75 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecurityMapping(t *testing.T) {
+	src := "./testdata/spec-proxy.yml"
+	_, doc, err := Compile(context.Background(), src)
+	require.NoError(t, err)
+	docv3, errs := doc.BuildV3Model()
+	require.NoError(t, errors.Join(errs...))
+
+	path, ok := docv3.Model.Paths.PathItems.Get("/profiles/{profile-id}")
+	require.True(t, ok, "path should be present")
+	ex, ok := path.Get.Extensions.Get("x-proxy-security")
+	require.True(t, ok, "security mapping should be emitted")
+	var mappings []SecurityMapping
+	require.NoError(t, ex.Decode(&mappings))
+	require.Equal(t, []SecurityMapping{{Proxy: "OAuth2", Upstream: "profileApiKey"}}, mappings)
+
+	for _, name := range []string{"OAuth2", "profileApiKey"} {
+		_, ok := docv3.Model.Components.SecuritySchemes.Get(name)
+		require.True(t, ok, "security scheme %s should exist", name)
+	}
+}
+
+func TestSecurityMappingInvalid(t *testing.T) {
+	upstream, err := os.ReadFile("./testdata/spec-profile.yml")
+	require.NoError(t, err)
+
+	tests := map[string]string{
+		"unknown proxy scheme":    "{proxy: Unknown, upstream: ApiKey}",
+		"unknown upstream scheme": "{proxy: OAuth2, upstream: Unknown}",
+	}
+	for name, mapping := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "spec-profile.yml"), upstream, 0644))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "spec-proxy.yml"), []byte(`
+openapi: "3.0.0"
+info:
+  title: "Proxy API"
+  version: "1.0.0"
+paths:
+  "/profiles/{profile-id}":
+    get:
+      operationId: GetProfile
+      x-proxy:
+        spec: ./spec-profile.yml
+        path: /tenants/{tenant-id}/profiles/{profile-id}
+        method: get
+        security:
+          - `+mapping+`
+components:
+  securitySchemes:
+    OAuth2:
+      type: oauth2
+      flows:
+        clientCredentials:
+          tokenUrl: "http://localhost/token"
+          scopes: {}
+`), 0644))
+
+			_, _, err := Compile(context.Background(), filepath.Join(dir, "spec-proxy.yml"))
+			require.ErrorContains(t, err, "invalid security mapping")
+		})
+	}
+}
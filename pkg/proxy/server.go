@@ -0,0 +1,116 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+)
+
+// ServerSelection selects which upstream server the proxy targets.
+// Without index or description the first server is selected.
+type ServerSelection struct {
+	Index       *int              `json:"index,omitempty" yaml:"index,omitempty"`
+	Description string            `json:"description,omitempty" yaml:"description,omitempty"`
+	Variables   map[string]string `json:"variables,omitempty" yaml:"variables,omitempty"`
+}
+
+type Environment struct {
+	Server *ServerSelection `json:"server,omitempty" yaml:"server,omitempty"`
+}
+
+func (p Proxy) getServerSelection(env string) *ServerSelection {
+	if e, ok := p.Environments[env]; ok && env != "" && e.Server != nil {
+		return e.Server
+	}
+	return p.Server
+}
+
+func (pop *ProxyOperation) GetUpstreamServerURL() string {
+	return pop.server
+}
+
+// resolveServer selects the upstream server of the operation. The selection of override, holding the server
+// declared by the operation itself, takes precedence over the one of the proxy.
+func (pop *ProxyOperation) resolveServer(override Proxy, env string) (err error) {
+	docv3, err := pop.GetOpenAPIV3Doc()
+	if err != nil {
+		return fmt.Errorf("fail to load upstream doc: %w", err)
+	}
+
+	sel := override.getServerSelection(env)
+	if sel == nil {
+		sel = pop.getServerSelection(env)
+	}
+	servers := docv3.Model.Servers
+	if len(servers) == 0 {
+		if sel != nil {
+			return fmt.Errorf("upstream doc has no servers to select")
+		}
+		return
+	}
+
+	var server *v3.Server
+	switch {
+	case sel == nil:
+		server = servers[0]
+
+	case sel.Index != nil:
+		if *sel.Index < 0 || *sel.Index >= len(servers) {
+			return fmt.Errorf("server index %d is out of range", *sel.Index)
+		}
+		server = servers[*sel.Index]
+
+	case sel.Description != "":
+		for _, s := range servers {
+			if s.Description == sel.Description {
+				server = s
+				break
+			}
+		}
+		if server == nil {
+			return fmt.Errorf("server with description '%s' not found", sel.Description)
+		}
+
+	default:
+		server = servers[0]
+	}
+
+	var vars map[string]string
+	if sel != nil {
+		vars = sel.Variables
+	}
+	pop.server, err = substituteServerVariables(server, vars)
+	return
+}
+
+func substituteServerVariables(server *v3.Server, values map[string]string) (url string, err error) {
+	url = server.URL
+	for name := range values {
+		if server.Variables == nil {
+			return "", fmt.Errorf("server variable '%s' is not defined", name)
+		}
+		if _, ok := server.Variables.Get(name); !ok {
+			return "", fmt.Errorf("server variable '%s' is not defined", name)
+		}
+	}
+	if server.Variables == nil {
+		return
+	}
+
+	for m := range orderedmap.Iterate(context.Background(), server.Variables) {
+		name, v := m.Key(), m.Value()
+		value, ok := values[name]
+		if !ok {
+			value = v.Default
+		}
+		if len(v.Enum) > 0 && !slices.Contains(v.Enum, value) {
+			return "", fmt.Errorf("value '%s' is not allowed for server variable '%s'", value, name)
+		}
+		url = strings.ReplaceAll(url, "{"+name+"}", value)
+	}
+	return
+}
@@ -0,0 +1,81 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerSelection(t *testing.T) {
+	src := "./testdata/spec-proxy.yml"
+	tests := map[string]map[string]string{
+		"": {
+			"/profiles/{profile-id}": "https://profile:8443",
+			"/orders/{order-id}":     "https://order:8443",
+		},
+		"dev": {
+			"/profiles/{profile-id}": "https://localhost:8443",
+			"/orders/{order-id}":     "https://order:8443",
+		},
+		"staging": {
+			"/profiles/{profile-id}": "https://profile:8443",
+			"/orders/{order-id}":     "https://order-staging:8443",
+		},
+	}
+	for env, servers := range tests {
+		t.Run(env, func(t *testing.T) {
+			_, doc, err := Compile(context.Background(), src, WithEnvironment(env))
+			require.NoError(t, err)
+			docv3, errs := doc.BuildV3Model()
+			require.NoError(t, errors.Join(errs...))
+
+			for path, server := range servers {
+				p, ok := docv3.Model.Paths.PathItems.Get(path)
+				require.True(t, ok, "path should be present")
+				ex, ok := p.Get.Extensions.Get("x-proxy-server")
+				require.True(t, ok, "server should be emitted")
+				require.Equal(t, server, ex.Value)
+				require.Empty(t, p.Get.Servers, "upstream servers should not be exposed")
+			}
+			require.Equal(t, "http://localhost", docv3.Model.Servers[0].URL, "servers of the proxy should be kept")
+		})
+	}
+
+	_, _, err := Compile(context.Background(), src, WithEnvironment("prod"))
+	require.ErrorContains(t, err, "environment 'prod' is not defined")
+}
+
+func TestServerSelectionOperationOverride(t *testing.T) {
+	src := "./testdata/spec-proxy-server.yml"
+	tests := map[string]map[string]string{
+		"": {
+			"get": "https://profile:8443",
+			"put": "https://localhost:8443",
+		},
+		"prod": {
+			"get": "https://profile:8443",
+			"put": "https://profile:8443",
+		},
+	}
+	for env, servers := range tests {
+		t.Run(env, func(t *testing.T) {
+			_, doc, err := Compile(context.Background(), src, WithEnvironment(env))
+			require.NoError(t, err)
+			docv3, errs := doc.BuildV3Model()
+			require.NoError(t, errors.Join(errs...))
+
+			p, ok := docv3.Model.Paths.PathItems.Get("/profiles/{profile-id}")
+			require.True(t, ok, "path should be present")
+			for method, server := range servers {
+				op := p.GetOperations().GetOrZero(method)
+				require.NotNil(t, op, method)
+				ex, ok := op.Extensions.Get("x-proxy-server")
+				require.True(t, ok, method)
+				require.Equal(t, server, ex.Value, method)
+			}
+			require.Equal(t, "http://localhost/v2", p.Put.Servers[0].URL, "servers of the proxy operation should be kept")
+		})
+	}
+}
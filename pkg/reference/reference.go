@@ -0,0 +1,85 @@
+// Package reference describes the `$ref`s of a spec that can not be resolved, and the other errors located
+// inside the files of a spec.
+package reference
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Error describes a `$ref` that can not be resolved, or any other error found at a location of a spec,
+// in which case Ref is empty.
+type Error struct {
+	// File is the path of the file containing the reference.
+	File string
+	// Line and Column locate the reference inside File.
+	Line   int
+	Column int
+	// Pointer is the JSON pointer of the reference inside File.
+	Pointer string
+	// Ref is the value of the offending `$ref`, if any.
+	Ref string
+	Err error
+}
+
+// Error formats the error the way compilers do: `file:line:column: message`.
+func (e *Error) Error() string {
+	b := strings.Builder{}
+	if e.File != "" {
+		b.WriteString(e.File + ":")
+	}
+	if e.Line > 0 {
+		b.WriteString(strconv.Itoa(e.Line) + ":" + strconv.Itoa(e.Column) + ":")
+	}
+	if b.Len() > 0 {
+		b.WriteString(" ")
+	}
+	if e.Ref != "" {
+		b.WriteString("$ref '" + e.Ref + "'")
+		if e.Pointer != "" {
+			b.WriteString(" at '" + e.Pointer + "'")
+		}
+		b.WriteString(": ")
+	} else if e.Pointer != "" {
+		b.WriteString("at '" + e.Pointer + "': ")
+	}
+	b.WriteString(e.Err.Error())
+	return b.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Errors aggregates every Error found in a single run.
+type Errors []*Error
+
+func (errs Errors) Error() string {
+	s := make([]string, 0, len(errs))
+	for _, e := range errs {
+		s = append(s, e.Error())
+	}
+	return strings.Join(s, "\n")
+}
+
+func (errs Errors) Unwrap() []error {
+	u := make([]error, 0, len(errs))
+	for _, e := range errs {
+		u = append(u, e)
+	}
+	return u
+}
+
+// Sort orders the errors by file then by position.
+func (errs Errors) Sort() {
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].File != errs[j].File {
+			return errs[i].File < errs[j].File
+		}
+		if errs[i].Line != errs[j].Line {
+			return errs[i].Line < errs[j].Line
+		}
+		return errs[i].Column < errs[j].Column
+	})
+}